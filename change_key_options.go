@@ -0,0 +1,22 @@
+package zfs
+
+// ChangeKeyOptions used in conjunction with ChangeKey() method and controls
+// how encryption key will be changed.
+type ChangeKeyOptions struct {
+	// Inherit will make FS inheriting key of it's parent encryption root
+	// instead of setting new key.
+	Inherit bool
+
+	// Load will load current key before changing it, if it's not loaded yet.
+	Load bool
+
+	// KeyFormat will set new format of encryption key.
+	KeyFormat string
+
+	// KeyLocation will set new location of encryption key.
+	KeyLocation string
+
+	// Key is a new key material, which will be passed via stdin. It requires
+	// key location to be `prompt`.
+	Key []byte
+}
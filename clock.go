@@ -0,0 +1,27 @@
+package zfs
+
+import "time"
+
+// clock provides current time and tickers for progress reports. It's
+// replaced in tests to drive reports deterministically.
+type clock interface {
+	// Now returns current time.
+	Now() time.Time
+
+	// Ticker returns channel which delivers ticks with given interval and
+	// function to stop it.
+	Ticker(interval time.Duration) (<-chan time.Time, func())
+}
+
+// systemClock is a clock which is used unless runner specifies another one.
+type systemClock struct{}
+
+func (systemClock) Now() time.Time {
+	return time.Now()
+}
+
+func (systemClock) Ticker(interval time.Duration) (<-chan time.Time, func()) {
+	ticker := time.NewTicker(interval)
+
+	return ticker.C, ticker.Stop
+}
@@ -1,33 +1,47 @@
 package zfs
 
 import (
+	"context"
+	"io"
+	"sync"
+
+	"github.com/kovetskiy/runcmd"
 	"github.com/reconquest/lexec-go"
-	"github.com/reconquest/ser-go"
 )
 
 // Command represents single command object eligible for execution.
 type Command struct {
 	*lexec.Execution
+
+	context context.Context
+	worker  runcmd.CmdWorker
+	stdout  io.Reader
+	watcher *watcher
 }
 
 // Output returns stdout, stderr and error, if program fails to run or returned
 // some stderr.
 func (command *Command) Output() ([]byte, []byte, error) {
-	stdout, stderr, err := command.Execution.Output()
-	if err != nil {
-		return stdout, stderr, ser.Errorf(
-			err,
-			"error while execution zfs command '%s'",
-			command.String(),
-		)
+	stdout, stderr, err := command.output()
+
+	return stdout, stderr, command.check(stderr, err)
+}
+
+// output is a same as Output(), but non-empty stderr is not considered as
+// error.
+func (command *Command) output() ([]byte, []byte, error) {
+	if err := command.canceled(); err != nil {
+		return nil, nil, err
 	}
 
-	if len(stderr) != 0 {
-		return stdout, stderr, ser.Errorf(
-			stderr,
-			"command returned non-empty stderr: '%s'",
-			command.String(),
-		)
+	command.watch()
+
+	stdout, stderr, err := command.Execution.Output()
+
+	command.watcher.stop()
+
+	if err := command.canceled(); err != nil {
+		return stdout, stderr, err
 	}
 
 	return stdout, stderr, err
@@ -39,3 +53,129 @@ func (command Command) Execute() error {
 
 	return err
 }
+
+// Start starts command without waiting for it to complete. If command has
+// context, command will be terminated as soon as context is done.
+func (command *Command) Start() error {
+	if err := command.canceled(); err != nil {
+		return err
+	}
+
+	err := command.Execution.Start()
+	if err != nil {
+		return err
+	}
+
+	command.watch()
+
+	return nil
+}
+
+// Wait waits for started command to complete. ErrCanceled will be returned
+// if command is terminated because of context is done.
+func (command *Command) Wait() error {
+	err := command.Execution.Wait()
+
+	command.watcher.stop()
+
+	if err := command.canceled(); err != nil {
+		return err
+	}
+
+	return err
+}
+
+// StdoutPipe returns pipe that will be connected to command stdout when
+// command is started. Pipe is closed by Kill().
+func (command *Command) StdoutPipe() (io.Reader, error) {
+	stdout, err := command.Execution.StdoutPipe()
+	if err != nil {
+		return nil, err
+	}
+
+	command.stdout = stdout
+
+	return stdout, nil
+}
+
+// Kill terminates started command. If underlying worker is not
+// KillableWorker (e.g. runcmd local worker), stdout pipe (if any) is closed
+// instead, so process will be terminated on the next write. ErrNotKillable
+// is returned if neither is possible (e.g. runcmd remote worker), command
+// keeps running then.
+func (command *Command) Kill() error {
+	if worker, ok := command.worker.(KillableWorker); ok {
+		return worker.Kill()
+	}
+
+	if closer, ok := command.stdout.(io.Closer); ok {
+		return closer.Close()
+	}
+
+	return ErrNotKillable{Command: command.String()}
+}
+
+// check returns error if program failed to run or returned some stderr.
+// Returned error is classified according to stderr contents, so it can be
+// matched with errors like ErrDatasetNotFound.
+func (command *Command) check(stderr []byte, err error) error {
+	if err == nil && len(stderr) == 0 {
+		return nil
+	}
+
+	if _, ok := err.(ErrCanceled); ok {
+		return err
+	}
+
+	return ErrCommand{
+		Command: command.String(),
+		Stderr:  stderr,
+		Err:     err,
+		Kind:    classifyStderr(stderr),
+	}
+}
+
+// canceled returns ErrCanceled if command context is done.
+func (command *Command) canceled() error {
+	if command.context == nil || command.context.Err() == nil {
+		return nil
+	}
+
+	return ErrCanceled{
+		Command: command.String(),
+		Err:     command.context.Err(),
+	}
+}
+
+// watch starts goroutine which kills command when context is done.
+func (command *Command) watch() {
+	if command.context == nil {
+		return
+	}
+
+	command.watcher = &watcher{done: make(chan struct{})}
+
+	go func(watcher *watcher) {
+		select {
+		case <-command.context.Done():
+			command.Kill()
+		case <-watcher.done:
+		}
+	}(command.watcher)
+}
+
+// watcher controls goroutine which is started by Command.watch().
+type watcher struct {
+	done chan struct{}
+	once sync.Once
+}
+
+func (watcher *watcher) stop() {
+	if watcher == nil {
+		return
+	}
+
+	watcher.once.Do(func() {
+		close(watcher.done)
+	})
+}
@@ -0,0 +1,99 @@
+package zfs
+
+import (
+	"bytes"
+	"io"
+
+	"github.com/reconquest/ser-go"
+)
+
+// commandStream is a running command which stdout is read incrementally,
+// while stderr is kept to be checked when command is complete.
+type commandStream struct {
+	command Command
+	stdout  io.Reader
+	stderr  *bytes.Buffer
+	done    bool
+}
+
+// startCommandStream starts given command and returns stream of it's stdout.
+func startCommandStream(command Command) (*commandStream, error) {
+	stderr := &bytes.Buffer{}
+	command.SetStderr(stderr)
+
+	stdout, err := command.StdoutPipe()
+	if err != nil {
+		return nil, ser.Errorf(
+			err,
+			"can't get stdout handle to %s",
+			command.String(),
+		)
+	}
+
+	err = command.Start()
+	if err != nil {
+		if _, ok := err.(ErrCanceled); ok {
+			return nil, err
+		}
+
+		return nil, ser.Errorf(
+			err,
+			"can't start %s",
+			command.String(),
+		)
+	}
+
+	return &commandStream{
+		command: command,
+		stdout:  stdout,
+		stderr:  stderr,
+	}, nil
+}
+
+// wait waits for command to complete after whole stdout is read and returns
+// error if command is failed.
+func (stream *commandStream) wait() error {
+	if stream.done {
+		return nil
+	}
+
+	stream.done = true
+
+	return stream.command.check(
+		stream.stderr.Bytes(),
+		stream.command.Wait(),
+	)
+}
+
+// fail returns error which occurred while reading stdout and terminates
+// command.
+func (stream *commandStream) fail(err error) error {
+	stream.close()
+
+	return ser.Errorf(
+		err,
+		"error while reading command output: '%s'",
+		stream.command.String(),
+	)
+}
+
+// close terminates command if it's still running. ErrNotKillable is
+// returned if command can't be terminated, because waiting for such command
+// means reading all of it's output.
+func (stream *commandStream) close() error {
+	if stream.done {
+		return nil
+	}
+
+	stream.done = true
+
+	err := stream.command.Kill()
+	if err != nil {
+		return err
+	}
+
+	// command is killed, so exit status is not relevant anymore
+	_ = stream.command.Wait()
+
+	return nil
+}
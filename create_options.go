@@ -0,0 +1,60 @@
+package zfs
+
+// CreateOptions used in conjunction with Create() method and controls
+// how new FS will be created.
+type CreateOptions struct {
+	// CreateParents will create all non-existing parent FS of given FS.
+	CreateParents bool
+
+	// NotMount will set that newly created FS should not be mounted. It's
+	// ignored for volumes, because they are never mounted.
+	NotMount bool
+
+	// Properties will be set on newly created FS at creation time.
+	Properties Properties
+
+	// VolumeSize will create volume of given size instead of filesystem.
+	VolumeSize Size
+
+	// Sparse will create volume without reservation. Can be used only when
+	// VolumeSize is specified.
+	Sparse bool
+
+	// BlockSize will set volume block size. Can be used only when
+	// VolumeSize is specified.
+	BlockSize Size
+
+	// Encryption will set encryption algorithm (e.g. `on` or `aes-256-gcm`)
+	// of new FS.
+	Encryption string
+
+	// KeyFormat will set format of encryption key: `raw`, `hex` or
+	// `passphrase`.
+	KeyFormat string
+
+	// KeyLocation will set location of encryption key, e.g. `prompt` or
+	// `file:///path/to/key`.
+	KeyLocation string
+
+	// Key is a encryption key material, which will be passed via stdin. It
+	// requires KeyLocation to be `prompt` or empty.
+	Key []byte
+}
+
+// Validate returns ErrVolumeOnlyOption if volume options are specified for
+// filesystem.
+func (options CreateOptions) Validate() error {
+	if options.VolumeSize > 0 {
+		return nil
+	}
+
+	if options.Sparse {
+		return ErrVolumeOnlyOption{Option: "-s"}
+	}
+
+	if options.BlockSize > 0 {
+		return ErrVolumeOnlyOption{Option: "-b"}
+	}
+
+	return nil
+}
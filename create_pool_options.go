@@ -0,0 +1,20 @@
+package zfs
+
+// CreatePoolOptions used in conjunction with Zpool.Create() method and
+// controls how new pool will be created.
+type CreatePoolOptions struct {
+	// Properties will be set as pool properties (e.g. `ashift`).
+	Properties Properties
+
+	// FSProperties will be set on root FS of pool.
+	FSProperties Properties
+
+	// Mountpoint will set mountpoint of root FS of pool.
+	Mountpoint string
+
+	// AltRoot will set alternate root directory of pool.
+	AltRoot string
+
+	// Force will use devices even if they appear in use.
+	Force bool
+}
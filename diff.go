@@ -0,0 +1,182 @@
+package zfs
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// DiffChangeType describes how file is changed between two snapshots.
+type DiffChangeType string
+
+const (
+	// DiffAdded means that file is created.
+	DiffAdded DiffChangeType = "+"
+
+	// DiffRemoved means that file is removed.
+	DiffRemoved DiffChangeType = "-"
+
+	// DiffModified means that file or it's metadata is modified.
+	DiffModified DiffChangeType = "M"
+
+	// DiffRenamed means that file is renamed, new path is stored in
+	// DiffChange.NewPath.
+	DiffRenamed DiffChangeType = "R"
+)
+
+// DiffFileType describes type of changed file, as reported by `zfs diff -F`.
+type DiffFileType string
+
+const (
+	// DiffFileRegular is a regular file.
+	DiffFileRegular DiffFileType = "F"
+
+	// DiffFileDirectory is a directory.
+	DiffFileDirectory DiffFileType = "/"
+
+	// DiffFileSymlink is a symbolic link.
+	DiffFileSymlink DiffFileType = "@"
+
+	// DiffFilePipe is a named pipe.
+	DiffFilePipe DiffFileType = "|"
+
+	// DiffFileSocket is a unix socket.
+	DiffFileSocket DiffFileType = "="
+
+	// DiffFileBlockDevice is a block device.
+	DiffFileBlockDevice DiffFileType = "B"
+
+	// DiffFileCharDevice is a character device.
+	DiffFileCharDevice DiffFileType = "C"
+
+	// DiffFileDoor is a door (Solaris only).
+	DiffFileDoor DiffFileType = ">"
+
+	// DiffFileEventPort is an event port (Solaris only).
+	DiffFileEventPort DiffFileType = "P"
+)
+
+// DiffChange represents single file change reported by `zfs diff`.
+type DiffChange struct {
+	// Change is a kind of change.
+	Change DiffChangeType
+
+	// FileType is a type of changed file.
+	FileType DiffFileType
+
+	// Time is a time of change (inode change time).
+	Time time.Time
+
+	// Path is an absolute path to changed file.
+	Path string
+
+	// NewPath is a path to renamed file, empty for other change types.
+	NewPath string
+}
+
+// DiffOptions used in conjunction with Diff() method and controls which
+// changes will be returned.
+type DiffOptions struct {
+	// Changes limits returned changes to given change types. All changes are
+	// returned if nothing is specified.
+	Changes []DiffChangeType
+}
+
+func (options DiffOptions) match(change DiffChange) bool {
+	if len(options.Changes) == 0 {
+		return true
+	}
+
+	for _, kind := range options.Changes {
+		if kind == change.Change {
+			return true
+		}
+	}
+
+	return false
+}
+
+// parseDiffChange parses fields of single `zfs diff -H -F -t` record.
+func parseDiffChange(fields []string) (DiffChange, error) {
+	if len(fields) < 4 {
+		return DiffChange{}, fmt.Errorf(
+			"unexpected diff record: %q", strings.Join(fields, "\t"),
+		)
+	}
+
+	changed, err := parseDiffTime(fields[0])
+	if err != nil {
+		return DiffChange{}, err
+	}
+
+	change := DiffChange{
+		Time:     changed,
+		Change:   DiffChangeType(fields[1]),
+		FileType: DiffFileType(fields[2]),
+		Path:     unescapeDiffPath(fields[3]),
+	}
+
+	switch {
+	case change.Change == DiffRenamed && len(fields) == 5:
+		change.NewPath = unescapeDiffPath(fields[4])
+
+	case len(fields) != 4:
+		return DiffChange{}, fmt.Errorf(
+			"unexpected diff record: %q", strings.Join(fields, "\t"),
+		)
+	}
+
+	return change, nil
+}
+
+// parseDiffTime parses `seconds.fraction` timestamp.
+func parseDiffTime(value string) (time.Time, error) {
+	seconds, nanoseconds := value, "0"
+	if dot := strings.IndexByte(value, '.'); dot >= 0 {
+		seconds, nanoseconds = value[:dot], value[dot+1:]
+	}
+
+	sec, err := strconv.ParseInt(seconds, 10, 64)
+	if err != nil {
+		return time.Time{}, fmt.Errorf("invalid diff time: %q", value)
+	}
+
+	if len(nanoseconds) > 9 {
+		nanoseconds = nanoseconds[:9]
+	}
+
+	nsec, err := strconv.ParseInt(
+		nanoseconds+strings.Repeat("0", 9-len(nanoseconds)), 10, 64,
+	)
+	if err != nil {
+		return time.Time{}, fmt.Errorf("invalid diff time: %q", value)
+	}
+
+	return time.Unix(sec, nsec), nil
+}
+
+// unescapeDiffPath decodes non-printable bytes and whitespace in paths, which
+// zfs diff escapes as backslash followed by four octal digits (`\0040`).
+func unescapeDiffPath(path string) string {
+	if strings.IndexByte(path, '\\') < 0 {
+		return path
+	}
+
+	result := make([]byte, 0, len(path))
+
+	for i := 0; i < len(path); i++ {
+		if path[i] == '\\' && i+4 < len(path) {
+			value, err := strconv.ParseUint(path[i+1:i+5], 8, 8)
+			if err == nil {
+				result = append(result, byte(value))
+				i += 4
+				continue
+			}
+		}
+
+		result = append(result, path[i])
+	}
+
+	return string(result)
+}
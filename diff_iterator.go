@@ -0,0 +1,85 @@
+package zfs
+
+import (
+	"io"
+)
+
+// DiffIterator iterates over changes which are read from running `zfs diff`
+// command, so large diffs are never buffered. Iterator must be closed if
+// iteration is stopped before Next() returns false.
+type DiffIterator struct {
+	stream  *commandStream
+	parser  *Parser
+	options DiffOptions
+	change  DiffChange
+	err     error
+}
+
+// DiffIter is a same as Diff(), but returns iterator which reads changes one
+// by one from running zfs command instead of returning all of them at once.
+func (zfs *ZFS) DiffIter(
+	from string,
+	to string,
+	options DiffOptions,
+) (*DiffIterator, error) {
+	args := []string{"diff", "-H", "-F", "-t", from}
+	if to != "" {
+		args = append(args, to)
+	}
+
+	stream, err := startCommandStream(zfs.Command(args...))
+	if err != nil {
+		return nil, err
+	}
+
+	return &DiffIterator{
+		stream:  stream,
+		parser:  NewParser(stream.stdout, 0),
+		options: options,
+	}, nil
+}
+
+// Next reads next change, which can be obtained by Change() method. It
+// returns false when there is no more changes or error occurred, which can be
+// obtained by Err() method.
+func (iterator *DiffIterator) Next() bool {
+	for !iterator.stream.done {
+		fields, err := iterator.parser.Next()
+		if err == io.EOF {
+			iterator.err = iterator.stream.wait()
+			return false
+		}
+
+		if err == nil {
+			iterator.change, err = parseDiffChange(fields)
+		}
+
+		if err != nil {
+			iterator.err = iterator.stream.fail(err)
+			return false
+		}
+
+		if iterator.options.match(iterator.change) {
+			return true
+		}
+	}
+
+	return false
+}
+
+// Change returns change which is read by last Next() call.
+func (iterator *DiffIterator) Change() DiffChange {
+	return iterator.change
+}
+
+// Err returns error which is occurred during iteration.
+func (iterator *DiffIterator) Err() error {
+	return iterator.err
+}
+
+// Close stops iteration and terminates zfs command if it's still running.
+// It's safe to call Close() several times. ErrNotKillable is returned if
+// command can't be terminated by runner, see Command.Kill().
+func (iterator *DiffIterator) Close() error {
+	return iterator.stream.close()
+}
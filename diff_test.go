@@ -0,0 +1,102 @@
+package zfs
+
+import (
+	"testing"
+	"time"
+
+	"github.com/kovetskiy/runcmd"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestZFS_Diff_ParsesChanges(t *testing.T) {
+	test := assert.New(t)
+
+	zfs, err := NewZFS()
+	test.NoError(err)
+
+	runner := expectCommands(
+		test, zfs,
+		[]string{"zfs", "diff", "-H", "-F", "-t", "zroot/a@1", "zroot/a@2"},
+	)
+	runner.Stdout = asBytes(
+		"1700000000.123456789\tM\t/\t/zroot/a",
+		"1700000001.000000000\t+\tF\t/zroot/a/new\\0040file",
+		"1700000002.5\tR\tF\t/zroot/a/old\t/zroot/a/d\\0011ir/renamed",
+		"1700000003.0\t-\t@\t/zroot/a/link",
+	)
+
+	changes, err := zfs.Diff("zroot/a@1", "zroot/a@2", DiffOptions{})
+	test.NoError(err)
+	test.Equal(
+		[]DiffChange{
+			{
+				Change:   DiffModified,
+				FileType: DiffFileDirectory,
+				Time:     time.Unix(1700000000, 123456789),
+				Path:     "/zroot/a",
+			},
+			{
+				Change:   DiffAdded,
+				FileType: DiffFileRegular,
+				Time:     time.Unix(1700000001, 0),
+				Path:     "/zroot/a/new file",
+			},
+			{
+				Change:   DiffRenamed,
+				FileType: DiffFileRegular,
+				Time:     time.Unix(1700000002, 500000000),
+				Path:     "/zroot/a/old",
+				NewPath:  "/zroot/a/d\tir/renamed",
+			},
+			{
+				Change:   DiffRemoved,
+				FileType: DiffFileSymlink,
+				Time:     time.Unix(1700000003, 0),
+				Path:     "/zroot/a/link",
+			},
+		},
+		changes,
+	)
+}
+
+func TestZFS_Diff_FiltersChangeTypes(t *testing.T) {
+	test := assert.New(t)
+
+	zfs, err := NewZFS()
+	test.NoError(err)
+
+	runner := expectCommands(
+		test, zfs,
+		[]string{"zfs", "diff", "-H", "-F", "-t", "zroot/a@1"},
+	)
+	runner.Stdout = asBytes(
+		"1700000000.0\tM\t/\t/zroot/a",
+		"1700000001.0\t+\tF\t/zroot/a/x",
+	)
+
+	changes, err := zfs.Diff(
+		"zroot/a@1", "",
+		DiffOptions{Changes: []DiffChangeType{DiffAdded}},
+	)
+	test.NoError(err)
+	test.Len(changes, 1)
+	test.Equal("/zroot/a/x", changes[0].Path)
+}
+
+func TestZFS_DiffIter_ReturnsErrorOnMalformedOutput(t *testing.T) {
+	test := assert.New(t)
+
+	zfs, err := NewZFS()
+	test.NoError(err)
+
+	zfs.SetRunner(&runcmd.MockRunner{
+		Stdout: asBytes("1700000000.0\tM\t/"),
+	})
+
+	iterator, err := zfs.DiffIter("zroot/a@1", "", DiffOptions{})
+	test.NoError(err)
+
+	test.False(iterator.Next())
+	test.Error(iterator.Err())
+	test.NoError(iterator.Close())
+}
@@ -1,6 +1,10 @@
 package zfs
 
-import "fmt"
+import (
+	"errors"
+	"fmt"
+	"strings"
+)
 
 type (
 	// ErrNone means that property has set to none value.
@@ -19,8 +23,175 @@ type (
 		Name  string
 		Value string
 	}
+
+	// ErrUnexpectedColumns means that zfs command output line contains
+	// amount of tab-separated fields which differs from expected.
+	ErrUnexpectedColumns struct {
+		Line     int
+		Expected int
+		Actual   int
+		Text     string
+	}
+
+	// ErrCanceled means that command was terminated because of it's context
+	// is done. Err is a context error, so errors.Is(err, context.Canceled)
+	// can be used to check it.
+	ErrCanceled struct {
+		Command string
+		Err     error
+	}
+
+	// ErrNotKillable means that running command can't be terminated, because
+	// it's runner doesn't support it.
+	ErrNotKillable struct {
+		Command string
+	}
+
+	// ErrCommand means that command failed or returned non-empty stderr.
+	// Kind is one of known errors like ErrDatasetNotFound, which is
+	// classified from stderr, so errors.Is() can be used to check it.
+	ErrCommand struct {
+		// Command is a command line which is failed.
+		Command string
+
+		// Stderr is a raw stderr of command.
+		Stderr []byte
+
+		// Err is an execution error, if any (e.g. non-zero exit code).
+		Err error
+
+		// Kind is a known error which corresponds to stderr or nil.
+		Kind error
+	}
+
+	// ErrSnapshotHeld means that snapshot can't be destroyed because of it
+	// has user holds. Err is an original error, which is ErrDatasetBusy.
+	ErrSnapshotHeld struct {
+		Snapshot string
+		Holds    []SnapshotHold
+		Err      error
+	}
+
+	// ErrIncompatibleSendOptions means that specified send options can't be
+	// used together.
+	ErrIncompatibleSendOptions struct {
+		First  string
+		Second string
+	}
+
+	// ErrInvalidIncrementalBase means that specified incremental base can't
+	// be used to send given snapshot.
+	ErrInvalidIncrementalBase struct {
+		Base   string
+		Reason string
+	}
+
+	// ErrInvalidListType means that FS of specified type can't be listed.
+	ErrInvalidListType struct {
+		Type Type
+	}
+
+	// ErrVolumeOnlyOption means that create option can be used only for
+	// volumes, but filesystem is created.
+	ErrVolumeOnlyOption struct {
+		Option string
+	}
+
+	// ErrUnsupportedListOption means that list option can't be used with
+	// list iterator.
+	ErrUnsupportedListOption struct {
+		Option string
+	}
+
+	// ErrInvalidVdevSpec means that pool layout can't be used to create
+	// pool.
+	ErrInvalidVdevSpec struct {
+		Type   VdevType
+		Reason string
+	}
+
+	// ErrNoSnapshots means that FS has no snapshots.
+	ErrNoSnapshots struct {
+		Name string
+	}
+
+	// ErrNoCommonSnapshot means that source and destination FS do not have
+	// any common snapshot, so incremental stream can't be sent.
+	ErrNoCommonSnapshot struct {
+		Source      string
+		Destination string
+	}
+
+	// ErrReplicationDiverged means that destination FS has snapshots which are
+	// newer than newest common snapshot with source FS.
+	ErrReplicationDiverged struct {
+		Destination string
+		Snapshots   []string
+	}
+)
+
+var (
+	// ErrDatasetNotFound means that specified FS does not exist.
+	ErrDatasetNotFound = errors.New("dataset does not exist")
+
+	// ErrDatasetExists means that FS with specified name already exists.
+	ErrDatasetExists = errors.New("dataset already exists")
+
+	// ErrDatasetBusy means that FS is in use and operation can't be done.
+	ErrDatasetBusy = errors.New("dataset is busy")
+
+	// ErrPermissionDenied means that zfs is run without required rights.
+	ErrPermissionDenied = errors.New("permission denied")
+
+	// ErrOutOfSpace means that there is not enough space in pool.
+	ErrOutOfSpace = errors.New("out of space")
+
+	// ErrHasChildren means that FS can't be destroyed without destroying
+	// it's descendents, snapshots or clones.
+	ErrHasChildren = errors.New("dataset has children")
+
+	// ErrNoSuchPool means that specified pool does not exist.
+	ErrNoSuchPool = errors.New("no such pool")
+
+	// ErrReceiveResumeState means that target FS contains partially received
+	// state, which should be either resumed or aborted.
+	ErrReceiveResumeState = errors.New("dataset contains partial receive state")
 )
 
+// stderrPatterns is a list of zfs stderr substrings (in lower case), which
+// are used to classify errors. Order matters, first matching pattern wins.
+var stderrPatterns = []struct {
+	substring string
+	err       error
+}{
+	{"partially-complete state", ErrReceiveResumeState},
+	{"resuming stream can be generated", ErrReceiveResumeState},
+	{"no such pool", ErrNoSuchPool},
+	{"dataset does not exist", ErrDatasetNotFound},
+	{"dataset already exists", ErrDatasetExists},
+	{"has children", ErrHasChildren},
+	{"has dependent clones", ErrHasChildren},
+	{"is busy", ErrDatasetBusy},
+	{"permission denied", ErrPermissionDenied},
+	{"insufficient privileges", ErrPermissionDenied},
+	{"out of space", ErrOutOfSpace},
+	{"no space left", ErrOutOfSpace},
+}
+
+// classifyStderr returns one of known errors which corresponds to zfs stderr
+// or nil if stderr is not recognized.
+func classifyStderr(stderr []byte) error {
+	text := strings.ToLower(string(stderr))
+
+	for _, pattern := range stderrPatterns {
+		if strings.Contains(text, pattern.substring) {
+			return pattern.err
+		}
+	}
+
+	return nil
+}
+
 // Error returns string representation of an error.
 func (err ErrNone) Error() string {
 	return fmt.Sprintf("value of '%s' is 'none'", err.Name)
@@ -39,3 +210,148 @@ func (err ErrNotBool) Error() string {
 		err.Value,
 	)
 }
+
+// Error returns string representation of an error.
+func (err ErrUnexpectedColumns) Error() string {
+	return fmt.Sprintf(
+		"line %d has %d columns, but %d expected: '%s'",
+		err.Line,
+		err.Actual,
+		err.Expected,
+		err.Text,
+	)
+}
+
+// Error returns string representation of an error.
+func (err ErrCanceled) Error() string {
+	return fmt.Sprintf("command %s is canceled: %s", err.Command, err.Err)
+}
+
+// Unwrap returns context error.
+func (err ErrCanceled) Unwrap() error {
+	return err.Err
+}
+
+// Error returns string representation of an error.
+func (err ErrNotKillable) Error() string {
+	return fmt.Sprintf(
+		"command %s can't be terminated early by it's runner",
+		err.Command,
+	)
+}
+
+// Error returns string representation of an error.
+func (err ErrCommand) Error() string {
+	message := fmt.Sprintf("command %s failed", err.Command)
+
+	if err.Err != nil {
+		message += ": " + err.Err.Error()
+	}
+
+	stderr := strings.TrimSpace(string(err.Stderr))
+	if stderr != "" {
+		message += ": " + stderr
+	}
+
+	return message
+}
+
+// Is returns true if target is the same as error kind.
+func (err ErrCommand) Is(target error) bool {
+	return err.Kind != nil && err.Kind == target
+}
+
+// Unwrap returns execution error.
+func (err ErrCommand) Unwrap() error {
+	return err.Err
+}
+
+// Error returns string representation of an error.
+func (err ErrNoSnapshots) Error() string {
+	return fmt.Sprintf("'%s' has no snapshots", err.Name)
+}
+
+// Error returns string representation of an error.
+func (err ErrNoCommonSnapshot) Error() string {
+	return fmt.Sprintf(
+		"'%s' and '%s' have no common snapshots",
+		err.Source,
+		err.Destination,
+	)
+}
+
+// Error returns string representation of an error.
+func (err ErrReplicationDiverged) Error() string {
+	return fmt.Sprintf(
+		"'%s' has snapshots newer than common one: %s",
+		err.Destination,
+		strings.Join(err.Snapshots, ", "),
+	)
+}
+
+// Error returns string representation of an error.
+func (err ErrIncompatibleSendOptions) Error() string {
+	return fmt.Sprintf(
+		"send options '%s' and '%s' can't be used together",
+		err.First,
+		err.Second,
+	)
+}
+
+// Error returns string representation of an error.
+func (err ErrSnapshotHeld) Error() string {
+	tags := []string{}
+	for _, hold := range err.Holds {
+		tags = append(tags, hold.Tag)
+	}
+
+	return fmt.Sprintf(
+		"snapshot '%s' is held: %s",
+		err.Snapshot,
+		strings.Join(tags, ", "),
+	)
+}
+
+// Unwrap returns original error.
+func (err ErrSnapshotHeld) Unwrap() error {
+	return err.Err
+}
+
+// Error returns string representation of an error.
+func (err ErrInvalidIncrementalBase) Error() string {
+	return fmt.Sprintf(
+		"invalid incremental base '%s': %s",
+		err.Base,
+		err.Reason,
+	)
+}
+
+// Error returns string representation of an error.
+func (err ErrInvalidListType) Error() string {
+	return fmt.Sprintf("type '%s' can't be listed", err.Type)
+}
+
+// Error returns string representation of an error.
+func (err ErrUnsupportedListOption) Error() string {
+	return fmt.Sprintf(
+		"list option '%s' is not supported by list iterator",
+		err.Option,
+	)
+}
+
+// Error returns string representation of an error.
+func (err ErrVolumeOnlyOption) Error() string {
+	return fmt.Sprintf(
+		"create option '%s' can be used only for volumes",
+		err.Option,
+	)
+}
+
+// Error returns string representation of an error.
+func (err ErrInvalidVdevSpec) Error() string {
+	if err.Type == VdevStripe {
+		return fmt.Sprintf("invalid vdev spec: %s", err.Reason)
+	}
+
+	return fmt.Sprintf("invalid %s vdev spec: %s", err.Type, err.Reason)
+}
@@ -0,0 +1,55 @@
+package zfs
+
+// Escalator describes command which is used to run zfs binary under
+// privileged rights, e.g. `sudo`.
+type Escalator struct {
+	// Binary is a name of escalation binary.
+	Binary string
+
+	// Args is a list of args which are passed to escalation binary before
+	// zfs binary name.
+	Args []string
+
+	// Interactive means that escalation binary may ask password on
+	// controlling terminal, so LocalRunner will run commands in foreground
+	// process group.
+	Interactive bool
+}
+
+var (
+	// EscalatorSudo runs commands using `sudo`.
+	EscalatorSudo = Escalator{Binary: "sudo", Interactive: true}
+
+	// EscalatorSudoNonInteractive runs commands using `sudo -n`, so sudo will
+	// fail instead of asking password.
+	EscalatorSudoNonInteractive = Escalator{
+		Binary: "sudo",
+		Args:   []string{"-n"},
+	}
+
+	// EscalatorDoas runs commands using `doas`.
+	EscalatorDoas = Escalator{Binary: "doas", Interactive: true}
+
+	// EscalatorPfexec runs commands using `pfexec`.
+	EscalatorPfexec = Escalator{Binary: "pfexec"}
+)
+
+// EscalatorSudoAs returns escalator which runs commands as specified user
+// using `sudo -n -u <user>`.
+func EscalatorSudoAs(user string) Escalator {
+	return Escalator{Binary: "sudo", Args: []string{"-n", "-u", user}}
+}
+
+// Wrap returns binary name and args which will run specified command under
+// privileged rights.
+func (escalator Escalator) Wrap(
+	name string,
+	args []string,
+) (string, []string) {
+	wrapped := make([]string, 0, len(escalator.Args)+len(args)+1)
+	wrapped = append(wrapped, escalator.Args...)
+	wrapped = append(wrapped, name)
+	wrapped = append(wrapped, args...)
+
+	return escalator.Binary, wrapped
+}
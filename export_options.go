@@ -0,0 +1,8 @@
+package zfs
+
+// ExportOptions used in conjunction with Export() method and controls
+// behaviour of pool export.
+type ExportOptions struct {
+	// Force will forcefully unmount all FS of pool.
+	Force bool
+}
@@ -1,5 +1,7 @@
 package zfs
 
+import "strings"
+
 // Type is a type of FS.
 type Type string
 
@@ -8,10 +10,31 @@ const (
 	TypeFileSystem Type = "filesystem"
 
 	// TypeSnapshot is a snapshot of common FS.
-	TypeSnapshot = "snapshot"
+	TypeSnapshot Type = "snapshot"
+
+	// TypeClone is a clone of common FS or snapshot. Clones are reported by
+	// zfs as filesystems, so it can't be used to filter listed FS.
+	TypeClone Type = "clone"
+
+	// TypeVolume is a block device FS.
+	TypeVolume Type = "volume"
+
+	// TypeBookmark is a bookmark of snapshot.
+	TypeBookmark Type = "bookmark"
+)
+
+// KeyStatus is a status of FS encryption key.
+type KeyStatus string
+
+const (
+	// KeyStatusAvailable means that key is loaded.
+	KeyStatusAvailable KeyStatus = "available"
 
-	// TypeClone is a clone of common FS or snapshot.
-	TypeClone = "clone"
+	// KeyStatusUnavailable means that key is not loaded.
+	KeyStatusUnavailable KeyStatus = "unavailable"
+
+	// KeyStatusNone means that FS is not encrypted.
+	KeyStatusNone KeyStatus = "-"
 )
 
 // FS represents single zfs filesystem.
@@ -35,6 +58,23 @@ func (fs *FS) GetProperty(name string) Property {
 	}
 }
 
+// GetPropertyOrigin returns name of FS which actually defines value of given
+// property: for inherited property it's the FS it is inherited from, for
+// locally set or received property it's given FS itself. Empty string will be
+// returned for default, read-only or non-existent properties.
+func (fs *FS) GetPropertyOrigin(name string) string {
+	property := fs.GetProperty(name)
+
+	switch property.Source.Kind {
+	case SourceInherit:
+		return property.Source.InheritedFrom
+	case SourceLocal, SourceReceived, SourceTemporary:
+		return fs.Name
+	default:
+		return ""
+	}
+}
+
 // GetUsedSize returns `used` property returned as Size type. It's how much
 // disk space is consumed by given FS.
 func (fs *FS) GetUsedSize() (Size, error) {
@@ -67,3 +107,51 @@ func (fs *FS) GetType() Type {
 func (fs *FS) IsFileSystem() bool {
 	return fs.GetType() == TypeFileSystem
 }
+
+// IsSnapshot returns true if given FS is snapshot.
+func (fs *FS) IsSnapshot() bool {
+	return fs.GetType() == TypeSnapshot
+}
+
+// IsVolume returns true if given FS is volume.
+func (fs *FS) IsVolume() bool {
+	return fs.GetType() == TypeVolume
+}
+
+// IsBookmark returns true if given FS is bookmark.
+func (fs *FS) IsBookmark() bool {
+	return fs.GetType() == TypeBookmark
+}
+
+// IsEncrypted returns true if given FS is encrypted.
+func (fs *FS) IsEncrypted() bool {
+	encryption := fs.GetProperty("encryption")
+
+	return !encryption.IsEmpty() && encryption.Value != "off"
+}
+
+// KeyStatus returns status of encryption key of given FS.
+func (fs *FS) KeyStatus() KeyStatus {
+	return KeyStatus(fs.GetProperty("keystatus").Value)
+}
+
+// EncryptionRoot returns name of FS which encryption key is used by given FS.
+// Empty string is returned if FS is not encrypted.
+func (fs *FS) EncryptionRoot() string {
+	root := fs.GetProperty("encryptionroot").Value
+	if root == "-" {
+		return ""
+	}
+
+	return root
+}
+
+// getDatasetName returns name of FS without snapshot or bookmark part, e.g.
+// `zroot/a` for `zroot/a@snapshot`.
+func getDatasetName(name string) string {
+	if index := strings.IndexAny(name, "@#"); index >= 0 {
+		return name[:index]
+	}
+
+	return name
+}
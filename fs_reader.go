@@ -0,0 +1,83 @@
+package zfs
+
+import "io"
+
+// fsReader reads `zfs get` output with propertiesColumns columns and groups
+// consecutive properties by FS name.
+type fsReader struct {
+	parser  *Parser
+	pending *FS
+}
+
+func newFSReader(reader io.Reader) *fsReader {
+	return &fsReader{
+		parser: NewParser(reader, 5),
+	}
+}
+
+// Next returns next FS as soon as all it's properties are read, which is
+// known only when first property of following FS is read or output is over.
+// io.EOF will be returned if there is no more FS.
+func (reader *fsReader) Next() (FS, error) {
+	for {
+		fields, err := reader.parser.Next()
+		if err == io.EOF {
+			if reader.pending == nil {
+				return FS{}, io.EOF
+			}
+
+			fs := *reader.pending
+			reader.pending = nil
+
+			return fs, nil
+		}
+
+		if err != nil {
+			return FS{}, err
+		}
+
+		property := Property{
+			Name:     fields[1],
+			Value:    fields[2],
+			Received: fields[3],
+			Source:   ParseSource(fields[4]),
+		}
+
+		if reader.pending != nil && reader.pending.Name == fields[0] {
+			reader.pending.Properties[property.Name] = property
+			continue
+		}
+
+		previous := reader.pending
+
+		reader.pending = &FS{
+			Name:       fields[0],
+			Properties: map[string]Property{property.Name: property},
+		}
+
+		if previous != nil {
+			return *previous, nil
+		}
+	}
+}
+
+// readFS reads all FS from `zfs get` output.
+func readFS(reader io.Reader) ([]FS, error) {
+	var (
+		fsReader = newFSReader(reader)
+		result   = []FS{}
+	)
+
+	for {
+		fs, err := fsReader.Next()
+		if err == io.EOF {
+			return result, nil
+		}
+
+		if err != nil {
+			return nil, err
+		}
+
+		result = append(result, fs)
+	}
+}
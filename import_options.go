@@ -0,0 +1,22 @@
+package zfs
+
+// ImportOptions used in conjunction with Import() method and controls
+// behaviour of pool import.
+type ImportOptions struct {
+	// SearchDirs is a list of directories or devices to search for pool
+	// devices. Default search path is used if empty.
+	SearchDirs []string
+
+	// NotMount will set that FS of imported pool should not be mounted.
+	NotMount bool
+
+	// AltRoot will set alternate root directory of imported pool.
+	AltRoot string
+
+	// Force will import pool even if it appears to be in use by another
+	// system.
+	Force bool
+
+	// Properties will be set as pool properties on import.
+	Properties Properties
+}
@@ -0,0 +1,16 @@
+package zfs
+
+// KeySource used in conjunction with LoadKey() method and describes where
+// encryption key should be loaded from.
+type KeySource struct {
+	// Location will override `keylocation` property of FS, e.g.
+	// `file:///path/to/key`. If empty, `keylocation` property is used, or
+	// `prompt` if Key is specified.
+	Location string
+
+	// Key is a key material, which will be passed via stdin.
+	Key []byte
+
+	// Recursive will load keys of all descendent encryption roots.
+	Recursive bool
+}
@@ -0,0 +1,84 @@
+package zfs
+
+import "io"
+
+// ListIterator iterates over FS which are read from running `zfs get` command.
+// Every FS is available as soon as all it's properties are read, so whole
+// command output is never buffered. Iterator must be closed if iteration is
+// stopped before Next() returns false.
+type ListIterator struct {
+	stream *commandStream
+	reader *fsReader
+	fs     FS
+	err    error
+}
+
+// ListIter is a same as ListWithOptions(), but returns iterator which reads
+// FS one by one from running zfs command instead of returning all of them at
+// once. Sorting is not supported, because it requires whole list to be read.
+func (zfs *ZFS) ListIter(
+	prefix string,
+	options ListOptions,
+) (*ListIterator, error) {
+	if len(options.Sort) > 0 {
+		return nil, ErrUnsupportedListOption{Option: "Sort"}
+	}
+
+	err := options.Validate()
+	if err != nil {
+		return nil, err
+	}
+
+	stream, err := startCommandStream(
+		zfs.getCommand(getListArgs(prefix, options)...),
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	return &ListIterator{
+		stream: stream,
+		reader: newFSReader(stream.stdout),
+	}, nil
+}
+
+// Next reads next FS, which can be obtained by FS() method. It returns false
+// when there is no more FS or error occurred, which can be obtained by Err()
+// method.
+func (iterator *ListIterator) Next() bool {
+	if iterator.stream.done {
+		return false
+	}
+
+	fs, err := iterator.reader.Next()
+	switch {
+	case err == nil:
+		iterator.fs = fs
+		return true
+
+	case err == io.EOF:
+		iterator.err = iterator.stream.wait()
+
+	default:
+		iterator.err = iterator.stream.fail(err)
+	}
+
+	return false
+}
+
+// FS returns FS which is read by last Next() call.
+func (iterator *ListIterator) FS() FS {
+	return iterator.fs
+}
+
+// Err returns error which is occurred during iteration.
+func (iterator *ListIterator) Err() error {
+	return iterator.err
+}
+
+// Close stops iteration and terminates zfs command if it's still running.
+// It's safe to call Close() several times. ErrNotKillable is returned if
+// command can't be terminated by runner, see Command.Kill().
+func (iterator *ListIterator) Close() error {
+	return iterator.stream.close()
+}
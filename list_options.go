@@ -0,0 +1,110 @@
+package zfs
+
+import (
+	"sort"
+	"strconv"
+)
+
+// ListOptions used in conjunction with ListWithOptions() method and controls
+// which FS and properties will be listed.
+type ListOptions struct {
+	// Recursive will list all descendents of specified FS.
+	Recursive bool
+
+	// Depth will limit recursive listing to specified depth, e.g. depth of
+	// 1 will list only specified FS and it's direct children. Implies
+	// Recursive if greater than zero.
+	Depth int
+
+	// Types will list only FS of specified types. All types are listed if
+	// empty. Only TypeFileSystem, TypeSnapshot, TypeVolume and TypeBookmark
+	// are accepted, because `zfs get -t` can't filter clones, which are
+	// listed as filesystems.
+	Types []Type
+
+	// Properties will retrieve only specified properties instead of all.
+	Properties []string
+
+	// Sort will sort listed FS by given property values, the same way as
+	// `-s` and `-S` flags of `zfs list` do. Sort properties are retrieved
+	// automatically even if they are not listed in Properties.
+	Sort []ListSort
+}
+
+// Validate returns ErrInvalidListType if options contain type which can't
+// be listed.
+func (options ListOptions) Validate() error {
+	for _, kind := range options.Types {
+		switch kind {
+		case TypeFileSystem, TypeSnapshot, TypeVolume, TypeBookmark:
+		default:
+			return ErrInvalidListType{Type: kind}
+		}
+	}
+
+	return nil
+}
+
+// ListSort describes single sort key for ListWithOptions().
+type ListSort struct {
+	// Property is a property name which values are compared.
+	Property string
+
+	// Descending will sort in descending order (`-S`) instead of ascending
+	// (`-s`).
+	Descending bool
+}
+
+// sortFS sorts given FS list by specified keys. Values which are integers
+// are compared numerically, others are compared as strings.
+func sortFS(filesystems []FS, keys []ListSort) {
+	if len(keys) == 0 {
+		return
+	}
+
+	sort.SliceStable(filesystems, func(i, j int) bool {
+		for _, key := range keys {
+			compare := compareValues(
+				filesystems[i].GetProperty(key.Property).Value,
+				filesystems[j].GetProperty(key.Property).Value,
+			)
+
+			if compare == 0 {
+				continue
+			}
+
+			if key.Descending {
+				return compare > 0
+			}
+
+			return compare < 0
+		}
+
+		return false
+	})
+}
+
+func compareValues(a, b string) int {
+	numberA, errA := strconv.ParseInt(a, 10, 64)
+	numberB, errB := strconv.ParseInt(b, 10, 64)
+
+	switch {
+	case errA == nil && errB == nil:
+		switch {
+		case numberA < numberB:
+			return -1
+		case numberA > numberB:
+			return 1
+		}
+
+		return 0
+
+	case a < b:
+		return -1
+
+	case a > b:
+		return 1
+	}
+
+	return 0
+}
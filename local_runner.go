@@ -0,0 +1,178 @@
+package zfs
+
+import (
+	"bytes"
+	"errors"
+	"io"
+	"os/exec"
+	"sync"
+	"syscall"
+
+	"github.com/kovetskiy/runcmd"
+)
+
+// KillableWorker is a command worker which is able to terminate started
+// process along with it's children (e.g. zfs which is started by sudo).
+// Commands are terminated on context cancellation or on early iterator close
+// only if their worker implements it.
+type KillableWorker interface {
+	runcmd.CmdWorker
+
+	// Kill terminates process. It's safe to call Kill() before process is
+	// started (process will not be started then) or after it's completed.
+	Kill() error
+}
+
+// LocalRunner runs commands on local host. Unlike runcmd local runner, it's
+// workers implement KillableWorker: every command is started in it's own
+// process group and is terminated by sending SIGTERM to the whole group, so
+// escalators like sudo relay signal to the actual zfs process.
+//
+// Commands which are run by interactive escalator (e.g. EscalatorSudo) are
+// started in foreground process group instead, so escalator is able to ask
+// password on controlling terminal. Such commands are terminated by sending
+// SIGTERM to escalator only, which relays it to zfs process.
+type LocalRunner struct{}
+
+// NewLocalRunner returns new local runner.
+func NewLocalRunner() *LocalRunner {
+	return &LocalRunner{}
+}
+
+// Command returns worker which will run specified binary with given args.
+func (runner *LocalRunner) Command(
+	name string,
+	args ...string,
+) runcmd.CmdWorker {
+	cmd := exec.Command(name, args...)
+	cmd.SysProcAttr = &syscall.SysProcAttr{Setpgid: true}
+
+	return &localWorker{cmd: cmd}
+}
+
+// foregroundCommand is a same as Command(), but process is started in
+// process group of current process.
+func (runner *LocalRunner) foregroundCommand(
+	name string,
+	args ...string,
+) runcmd.CmdWorker {
+	return &localWorker{cmd: exec.Command(name, args...), foreground: true}
+}
+
+// localWorker is a worker returned by LocalRunner.
+type localWorker struct {
+	cmd        *exec.Cmd
+	foreground bool
+
+	mutex   sync.Mutex
+	started bool
+	killed  bool
+	done    bool
+}
+
+var errKilled = errors.New("process is killed before start")
+
+func (worker *localWorker) Start() error {
+	worker.mutex.Lock()
+	defer worker.mutex.Unlock()
+
+	if worker.killed {
+		return errKilled
+	}
+
+	err := worker.cmd.Start()
+	if err != nil {
+		return err
+	}
+
+	worker.started = true
+
+	return nil
+}
+
+func (worker *localWorker) Wait() error {
+	err := worker.cmd.Wait()
+
+	worker.mutex.Lock()
+	worker.done = true
+	worker.mutex.Unlock()
+
+	return err
+}
+
+func (worker *localWorker) Run() error {
+	err := worker.Start()
+	if err != nil {
+		return err
+	}
+
+	return worker.Wait()
+}
+
+func (worker *localWorker) Output() ([]byte, []byte, error) {
+	var stdout, stderr bytes.Buffer
+
+	worker.cmd.Stdout = &stdout
+	worker.cmd.Stderr = &stderr
+
+	err := worker.Run()
+
+	return stdout.Bytes(), stderr.Bytes(), err
+}
+
+func (worker *localWorker) Kill() error {
+	worker.mutex.Lock()
+	defer worker.mutex.Unlock()
+
+	worker.killed = true
+
+	if !worker.started || worker.done {
+		return nil
+	}
+
+	pid := worker.cmd.Process.Pid
+	if !worker.foreground {
+		// negative pid means whole process group, which is created by
+		// Setpgid
+		pid = -pid
+	}
+
+	err := syscall.Kill(pid, syscall.SIGTERM)
+	if err == syscall.ESRCH {
+		return nil
+	}
+
+	return err
+}
+
+func (worker *localWorker) StdinPipe() (io.WriteCloser, error) {
+	return worker.cmd.StdinPipe()
+}
+
+func (worker *localWorker) StdoutPipe() (io.Reader, error) {
+	return worker.cmd.StdoutPipe()
+}
+
+func (worker *localWorker) StderrPipe() (io.Reader, error) {
+	return worker.cmd.StderrPipe()
+}
+
+func (worker *localWorker) SetStdout(stdout io.Writer) {
+	worker.cmd.Stdout = stdout
+}
+
+func (worker *localWorker) SetStderr(stderr io.Writer) {
+	worker.cmd.Stderr = stderr
+}
+
+func (worker *localWorker) SetStdin(stdin io.Reader) {
+	worker.cmd.Stdin = stdin
+}
+
+func (worker *localWorker) GetArgs() []string {
+	return worker.cmd.Args
+}
+
+func (worker *localWorker) CmdError() error {
+	return nil
+}
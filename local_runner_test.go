@@ -0,0 +1,298 @@
+package zfs
+
+import (
+	"context"
+	"errors"
+	"io"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"sync"
+	"syscall"
+	"testing"
+	"time"
+
+	"github.com/kovetskiy/runcmd"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestLocalRunner_WithContext_KillsRunningCommand(t *testing.T) {
+	test := assert.New(t)
+
+	zfs, err := NewZFS()
+	test.NoError(err)
+
+	zfs.Runner.Binary = "sleep"
+
+	ctx, cancel := context.WithTimeout(
+		context.Background(),
+		100*time.Millisecond,
+	)
+	defer cancel()
+
+	started := time.Now()
+
+	err = zfs.WithContext(ctx).Command("5").Execute()
+	test.True(errors.Is(err, context.DeadlineExceeded))
+	test.Less(int64(time.Since(started)), int64(2*time.Second))
+}
+
+func TestLocalRunner_WithContext_KillsEscalatorAndItsChild(t *testing.T) {
+	test := assert.New(t)
+
+	zfs, err := NewZFS()
+	test.NoError(err)
+
+	zfs.Runner.Binary = "sleep"
+
+	// like sudo, shell runs command as a child instead of exec'ing it, and
+	// child holds stdout open, so command can't complete until child exits
+	zfs = zfs.WithEscalator(Escalator{
+		Binary: "sh",
+		Args:   []string{"-c", `"$@"; exit $?`, "sh"},
+	})
+
+	ctx, cancel := context.WithTimeout(
+		context.Background(),
+		100*time.Millisecond,
+	)
+	defer cancel()
+
+	started := time.Now()
+
+	err = zfs.WithContext(ctx).Command("5").Execute()
+	test.True(errors.Is(err, context.DeadlineExceeded))
+	test.Less(int64(time.Since(started)), int64(2*time.Second))
+}
+
+func TestLocalRunner_WithContext_KillsRunningSend(t *testing.T) {
+	test := assert.New(t)
+
+	zfs, err := NewZFS()
+	test.NoError(err)
+
+	zfs.Runner.Binary = writeScript(t, "sleep 5")
+
+	ctx, cancel := context.WithTimeout(
+		context.Background(),
+		100*time.Millisecond,
+	)
+	defer cancel()
+
+	started := time.Now()
+
+	err = zfs.WithContext(ctx).Send("a@b", ioutil.Discard, SendOptions{})
+	test.True(errors.Is(err, context.DeadlineExceeded))
+	test.Less(int64(time.Since(started)), int64(2*time.Second))
+}
+
+// writeScript writes shell script with given body into temporary directory
+// and returns it's path.
+func writeScript(t *testing.T, body string) string {
+	path := filepath.Join(t.TempDir(), "script")
+
+	err := ioutil.WriteFile(path, []byte("#!/bin/sh\n"+body+"\n"), 0755)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	return path
+}
+
+func TestLocalRunner_ListIter_CloseStopsCommandEarly(t *testing.T) {
+	test := assert.New(t)
+
+	zfs, err := NewZFS()
+	test.NoError(err)
+
+	// writes much more than pipe buffer and never ends by itself
+	zfs.Runner.Binary = writeScript(t, listScript("while :"))
+
+	assertListIterCloses(test, zfs)
+}
+
+func TestLocalRunner_ListIter_CloseFailsForNotKillableCommand(t *testing.T) {
+	test := assert.New(t)
+
+	zfs, err := NewZFS()
+	test.NoError(err)
+
+	zfs.Runner.Binary = writeScript(t, listScript("while :"))
+
+	runner := &notKillableRunner{runner: NewLocalRunner()}
+	defer runner.kill()
+
+	zfs.SetRunner(runner)
+
+	iterator, err := zfs.ListIter("zroot", ListOptions{})
+	test.NoError(err)
+
+	test.True(iterator.Next())
+	test.IsType(ErrNotKillable{}, iterator.Close())
+	test.NoError(iterator.Close())
+}
+
+func assertListIterCloses(test *assert.Assertions, zfs *ZFS) {
+	iterator, err := zfs.ListIter("zroot", ListOptions{})
+	test.NoError(err)
+
+	test.True(iterator.Next())
+	test.Equal("zroot/1", iterator.FS().Name)
+
+	done := make(chan struct{})
+	go func() {
+		test.NoError(iterator.Close())
+		close(done)
+	}()
+
+	select {
+	case <-done:
+	case <-time.After(5 * time.Second):
+		test.Fail("iterator is not closed")
+	}
+}
+
+// listScript returns shell script which prints `zfs get` output for
+// filesystems zroot/1, zroot/2 and so on while given loop condition holds.
+func listScript(loop string) string {
+	return `i=0; ` + loop + `; do i=$((i+1)); ` +
+		`printf 'zroot/%d\ttype\tfilesystem\t-\t-\n' $i; ` +
+		`printf 'zroot/%d\tused\t1024\t-\t-\n' $i; done`
+}
+
+// notKillableRunner hides Kill() method of workers as well as Close() method
+// of their stdout pipes, like runcmd remote runner does.
+type notKillableRunner struct {
+	runner  *LocalRunner
+	workers []runcmd.CmdWorker
+}
+
+func (runner *notKillableRunner) Command(
+	name string,
+	args ...string,
+) runcmd.CmdWorker {
+	worker := runner.runner.Command(name, args...)
+
+	runner.workers = append(runner.workers, worker)
+
+	return &notKillableWorker{worker}
+}
+
+// kill terminates all started commands, which are left running by test.
+func (runner *notKillableRunner) kill() {
+	for _, worker := range runner.workers {
+		_ = worker.(KillableWorker).Kill()
+		_ = worker.Wait()
+	}
+}
+
+type notKillableWorker struct {
+	runcmd.CmdWorker
+}
+
+func (worker *notKillableWorker) StdoutPipe() (io.Reader, error) {
+	stdout, err := worker.CmdWorker.StdoutPipe()
+
+	return struct{ io.Reader }{stdout}, err
+}
+
+func TestLocalRunner_WithSudo_IsSafeForConcurrentUse(t *testing.T) {
+	test := assert.New(t)
+
+	// fake sudo marks output and runs given command
+	bin := t.TempDir()
+	err := ioutil.WriteFile(
+		filepath.Join(bin, "sudo"),
+		[]byte("#!/bin/sh\necho sudo\nexec \"$@\"\n"),
+		0755,
+	)
+	test.NoError(err)
+
+	t.Setenv("PATH", bin+string(os.PathListSeparator)+os.Getenv("PATH"))
+
+	zfs, err := NewZFS()
+	test.NoError(err)
+
+	zfs.Runner.Binary = "echo"
+
+	var group sync.WaitGroup
+	for i := 0; i < 20; i++ {
+		group.Add(2)
+
+		go func() {
+			defer group.Done()
+
+			command := zfs.WithSudo().Command("a")
+
+			stdout, _, err := command.Output()
+			test.NoError(err)
+			test.Equal("sudo\na\n", string(stdout))
+		}()
+
+		go func() {
+			defer group.Done()
+
+			command := zfs.Command("b")
+
+			stdout, _, err := command.Output()
+			test.NoError(err)
+			test.Equal("b\n", string(stdout))
+		}()
+	}
+
+	group.Wait()
+}
+
+func TestLocalRunner_WithSudo_RunsInForegroundProcessGroup(t *testing.T) {
+	test := assert.New(t)
+
+	// fake sudo prints it's process group, real sudo can ask password on
+	// terminal only if it's run in foreground process group
+	script := "#!/bin/sh\n" +
+		"ps -o pgid= -p $$\n" +
+		"[ \"$1\" = -n ] && shift\n" +
+		"exec \"$@\"\n"
+
+	bin := t.TempDir()
+	err := ioutil.WriteFile(filepath.Join(bin, "sudo"), []byte(script), 0755)
+	test.NoError(err)
+
+	t.Setenv("PATH", bin+string(os.PathListSeparator)+os.Getenv("PATH"))
+
+	zfs, err := NewZFS()
+	test.NoError(err)
+
+	zfs.Runner.Binary = "true"
+
+	foreground := strconv.Itoa(syscall.Getpgrp())
+
+	pgid := func(command Command) string {
+		stdout, _, err := command.Output()
+		test.NoError(err)
+
+		return strings.TrimSpace(string(stdout))
+	}
+
+	test.Equal(foreground, pgid(zfs.WithSudo().Command()))
+	test.Equal(foreground, pgid(zfs.Sudo().Command()))
+	test.NotEqual(
+		foreground,
+		pgid(zfs.WithEscalator(EscalatorSudoNonInteractive).Command()),
+	)
+
+	zfs.Runner.Binary = "sleep"
+
+	ctx, cancel := context.WithTimeout(
+		context.Background(),
+		100*time.Millisecond,
+	)
+	defer cancel()
+
+	started := time.Now()
+
+	err = zfs.WithSudo().WithContext(ctx).Command("5").Execute()
+	test.True(errors.Is(err, context.DeadlineExceeded))
+	test.Less(int64(time.Since(started)), int64(2*time.Second))
+}
@@ -0,0 +1,63 @@
+package zfs
+
+import (
+	"bufio"
+	"io"
+	"strings"
+)
+
+// Parser reads output of zfs commands which is produced in scripting mode
+// (`-H` flag), where every line is single record and fields are separated by
+// exactly one tab character. Unlike whitespace-based scanning, fields with
+// spaces (e.g. user properties or mountpoints) are preserved as is.
+type Parser struct {
+	scanner *bufio.Scanner
+	columns int
+	line    int
+}
+
+// NewParser returns parser which reads records from given reader. Every
+// record is expected to have exactly specified amount of columns; zero
+// columns means that any amount of columns is allowed.
+func NewParser(reader io.Reader, columns int) *Parser {
+	scanner := bufio.NewScanner(reader)
+	scanner.Buffer(make([]byte, 0, 64*1024), 16*1024*1024)
+
+	return &Parser{
+		scanner: scanner,
+		columns: columns,
+	}
+}
+
+// Next returns fields of next record. io.EOF will be returned if there is no
+// more records. Empty lines are skipped.
+func (parser *Parser) Next() ([]string, error) {
+	for parser.scanner.Scan() {
+		parser.line++
+
+		line := strings.TrimSuffix(parser.scanner.Text(), "\r")
+		if line == "" {
+			continue
+		}
+
+		fields := strings.Split(line, "\t")
+
+		if parser.columns > 0 && len(fields) != parser.columns {
+			return nil, ErrUnexpectedColumns{
+				Line:     parser.line,
+				Expected: parser.columns,
+				Actual:   len(fields),
+				Text:     line,
+			}
+		}
+
+		return fields, nil
+	}
+
+	err := parser.scanner.Err()
+	if err != nil {
+		return nil, err
+	}
+
+	return nil, io.EOF
+}
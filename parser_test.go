@@ -0,0 +1,44 @@
+package zfs
+
+import (
+	"io"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestParser_Next_SplitsFieldsByTabs(t *testing.T) {
+	test := assert.New(t)
+
+	parser := NewParser(
+		strings.NewReader("a b\tc\t\td\n\nx\ty\tz\tw\n"),
+		4,
+	)
+
+	fields, err := parser.Next()
+	test.NoError(err)
+	test.Equal([]string{"a b", "c", "", "d"}, fields)
+
+	fields, err = parser.Next()
+	test.NoError(err)
+	test.Equal([]string{"x", "y", "z", "w"}, fields)
+
+	_, err = parser.Next()
+	test.Equal(io.EOF, err)
+}
+
+func TestParser_Next_ReturnsErrorOnUnexpectedColumns(t *testing.T) {
+	test := assert.New(t)
+
+	parser := NewParser(strings.NewReader("a\tb\nc\td\te\n"), 2)
+
+	_, err := parser.Next()
+	test.NoError(err)
+
+	_, err = parser.Next()
+	test.Equal(
+		ErrUnexpectedColumns{Line: 2, Expected: 2, Actual: 3, Text: "c\td\te"},
+		err,
+	)
+}
@@ -0,0 +1,171 @@
+package zfs
+
+import (
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+)
+
+// Health is a health state of pool or vdev.
+type Health string
+
+const (
+	// HealthOnline means that device is working normally.
+	HealthOnline Health = "ONLINE"
+
+	// HealthDegraded means that device has failed redundant children, but
+	// still can operate.
+	HealthDegraded Health = "DEGRADED"
+
+	// HealthFaulted means that device is completely inaccessible.
+	HealthFaulted Health = "FAULTED"
+
+	// HealthOffline means that device is explicitly taken offline.
+	HealthOffline Health = "OFFLINE"
+
+	// HealthUnavailable means that device can't be opened.
+	HealthUnavailable Health = "UNAVAIL"
+
+	// HealthRemoved means that device is physically removed.
+	HealthRemoved Health = "REMOVED"
+
+	// HealthSuspended means that pool is suspended because of I/O failures.
+	HealthSuspended Health = "SUSPENDED"
+
+	// HealthAvailable means that hot spare is available for use.
+	HealthAvailable Health = "AVAIL"
+
+	// HealthInUse means that hot spare is currently in use.
+	HealthInUse Health = "INUSE"
+)
+
+// poolColumns is a list of columns which is requested from `zpool list` and
+// expected by readPools().
+var poolColumns = []string{
+	"name",
+	"size",
+	"alloc",
+	"free",
+	"frag",
+	"cap",
+	"dedup",
+	"health",
+	"altroot",
+}
+
+// Pool represents single imported pool as reported by `zpool list`.
+type Pool struct {
+	// Name is a pool name.
+	Name string
+
+	// Size is a total pool size.
+	Size Size
+
+	// Allocated is an amount of allocated space.
+	Allocated Size
+
+	// Free is an amount of free space.
+	Free Size
+
+	// Fragmentation is a free space fragmentation in percents. It's zero if
+	// pool does not report fragmentation.
+	Fragmentation int
+
+	// Capacity is a percentage of used space.
+	Capacity int
+
+	// Dedup is a deduplication ratio.
+	Dedup float64
+
+	// Health is a pool health.
+	Health Health
+
+	// AltRoot is an alternate root directory, empty if not set.
+	AltRoot string
+}
+
+// readPools reads `zpool list -H -p` output with poolColumns.
+func readPools(reader io.Reader) ([]Pool, error) {
+	var (
+		parser = NewParser(reader, len(poolColumns))
+		pools  = []Pool{}
+	)
+
+	for {
+		fields, err := parser.Next()
+		if err == io.EOF {
+			return pools, nil
+		}
+
+		if err != nil {
+			return nil, err
+		}
+
+		pool, err := parsePool(fields)
+		if err != nil {
+			return nil, err
+		}
+
+		pools = append(pools, pool)
+	}
+}
+
+func parsePool(fields []string) (Pool, error) {
+	pool := Pool{
+		Name:   fields[0],
+		Health: Health(fields[7]),
+	}
+
+	if fields[8] != "-" {
+		pool.AltRoot = fields[8]
+	}
+
+	sizes := []*Size{&pool.Size, &pool.Allocated, &pool.Free}
+	for i, size := range sizes {
+		value, err := parsePoolNumber(fields[i+1])
+		if err != nil {
+			return Pool{}, err
+		}
+
+		*size = Size(value)
+	}
+
+	percents := []*int{&pool.Fragmentation, &pool.Capacity}
+	for i, percent := range percents {
+		value, err := parsePoolNumber(fields[i+4])
+		if err != nil {
+			return Pool{}, err
+		}
+
+		*percent = int(value)
+	}
+
+	dedup := strings.TrimSuffix(fields[6], "x")
+	if dedup != "-" {
+		value, err := strconv.ParseFloat(dedup, 64)
+		if err != nil {
+			return Pool{}, fmt.Errorf("invalid dedup ratio: %q", fields[6])
+		}
+
+		pool.Dedup = value
+	}
+
+	return pool, nil
+}
+
+// parsePoolNumber parses exact numeric value of pool property, optionally
+// followed by percent sign. Value `-` means that property is not available
+// and is treated as zero.
+func parsePoolNumber(value string) (int64, error) {
+	if value == "-" {
+		return 0, nil
+	}
+
+	result, err := strconv.ParseInt(strings.TrimSuffix(value, "%"), 10, 64)
+	if err != nil {
+		return 0, fmt.Errorf("invalid numeric value: %q", value)
+	}
+
+	return result, nil
+}
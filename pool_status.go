@@ -0,0 +1,275 @@
+package zfs
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+// Vdev represents single virtual device in pool configuration tree.
+type Vdev struct {
+	// Name is a vdev name, e.g. `mirror-0` or `sda`.
+	Name string
+
+	// State is a vdev health. It's empty for vdevs that do not report it.
+	State Health
+
+	// Read is an amount of read errors.
+	Read uint64
+
+	// Write is an amount of write errors.
+	Write uint64
+
+	// Checksum is an amount of checksum errors.
+	Checksum uint64
+
+	// Message is an additional text printed after vdev state, e.g.
+	// `(resilvering)` or `was /dev/sdb1`.
+	Message string
+
+	// Children is a list of nested vdevs.
+	Children []Vdev
+}
+
+// PoolStatus represents pool status as reported by `zpool status`.
+type PoolStatus struct {
+	// Name is a pool name.
+	Name string
+
+	// State is a pool health.
+	State Health
+
+	// Status is a description of pool problem, if any.
+	Status string
+
+	// Action is a recommended action to fix pool problem, if any.
+	Action string
+
+	// See is a link to documentation about pool problem, if any.
+	See string
+
+	// Scan is a text description of last or current scrub or resilver.
+	Scan string
+
+	// Vdevs is a root of configuration tree, which is the pool itself.
+	Vdevs Vdev
+
+	// Logs is a list of separate intent log devices.
+	Logs []Vdev
+
+	// Cache is a list of cache devices.
+	Cache []Vdev
+
+	// Spares is a list of hot spares.
+	Spares []Vdev
+
+	// Special is a list of special allocation class devices.
+	Special []Vdev
+
+	// Dedup is a list of deduplication table devices.
+	Dedup []Vdev
+
+	// Errors is a description of data errors, e.g. `No known data errors`.
+	Errors string
+}
+
+// poolStatusKey matches beginning of `zpool status` section, keys are
+// right-aligned with spaces.
+var poolStatusKey = regexp.MustCompile(`^ *([a-z]+):(?: (.*))?$`)
+
+type vdevEntry struct {
+	depth int
+	vdev  Vdev
+}
+
+// parsePoolStatus parses `zpool status` output for single pool.
+func parsePoolStatus(reader io.Reader) (PoolStatus, error) {
+	var (
+		scanner = bufio.NewScanner(reader)
+		status  = PoolStatus{}
+		entries = []vdevEntry{}
+		texts   = map[string]*string{
+			"pool":   &status.Name,
+			"status": &status.Status,
+			"action": &status.Action,
+			"see":    &status.See,
+			"scan":   &status.Scan,
+			"errors": &status.Errors,
+		}
+		section string
+		state   string
+	)
+
+	for scanner.Scan() {
+		line := strings.TrimRight(scanner.Text(), " \r")
+		if strings.TrimSpace(line) == "" {
+			continue
+		}
+
+		// errors section is always last and may contain arbitrary file
+		// names, so it's never split into sections.
+		matches := poolStatusKey.FindStringSubmatch(line)
+		if matches != nil && section != "errors" {
+			section = matches[1]
+
+			switch {
+			case section == "state":
+				state = matches[2]
+
+			case texts[section] != nil:
+				*texts[section] = matches[2]
+			}
+
+			continue
+		}
+
+		if section == "config" {
+			entry, ok := parseVdevLine(line)
+			if ok {
+				entries = append(entries, entry)
+			}
+
+			continue
+		}
+
+		text := texts[section]
+		if text == nil {
+			continue
+		}
+
+		if *text != "" {
+			*text += "\n"
+		}
+
+		*text += strings.TrimSpace(line)
+	}
+
+	err := scanner.Err()
+	if err != nil {
+		return PoolStatus{}, err
+	}
+
+	if status.Name == "" {
+		return PoolStatus{}, fmt.Errorf("pool name is not found in status")
+	}
+
+	status.State = Health(state)
+
+	vdevs, rest := buildVdevTree(entries, 0)
+	if len(rest) > 0 {
+		return PoolStatus{}, fmt.Errorf(
+			"unexpected indentation of vdev %q", rest[0].vdev.Name,
+		)
+	}
+
+	if len(vdevs) == 0 {
+		return status, nil
+	}
+
+	status.Vdevs = vdevs[0]
+
+	for _, group := range vdevs[1:] {
+		switch group.Name {
+		case "logs":
+			status.Logs = group.Children
+		case "cache":
+			status.Cache = group.Children
+		case "spares":
+			status.Spares = group.Children
+		case "special":
+			status.Special = group.Children
+		case "dedup":
+			status.Dedup = group.Children
+		default:
+			return PoolStatus{}, fmt.Errorf(
+				"unexpected vdev group %q", group.Name,
+			)
+		}
+	}
+
+	return status, nil
+}
+
+// parseVdevLine parses single line of config section. Indentation (two
+// spaces per level after leading tab) defines vdev depth. Header line is
+// skipped.
+func parseVdevLine(line string) (vdevEntry, bool) {
+	line = strings.TrimPrefix(line, "\t")
+	indent := len(line) - len(strings.TrimLeft(line, " "))
+
+	fields := strings.Fields(line)
+	if fields[0] == "NAME" && indent == 0 {
+		return vdevEntry{}, false
+	}
+
+	entry := vdevEntry{
+		depth: indent / 2,
+		vdev:  Vdev{Name: fields[0]},
+	}
+
+	if len(fields) < 2 {
+		return entry, true
+	}
+
+	entry.vdev.State = Health(fields[1])
+	message := fields[2:]
+
+	if len(fields) >= 5 {
+		counts, ok := parseVdevCounts(fields[2:5])
+		if ok {
+			entry.vdev.Read = counts[0]
+			entry.vdev.Write = counts[1]
+			entry.vdev.Checksum = counts[2]
+
+			message = fields[5:]
+		}
+	}
+
+	entry.vdev.Message = strings.Join(message, " ")
+
+	return entry, true
+}
+
+// parseVdevCounts parses read, write and checksum error counts, which are
+// exact when `-p` is used, but can be in human-readable form (e.g. `1.2K`)
+// otherwise. False is returned if fields are not counts, e.g. for spares,
+// which have text message right after state.
+func parseVdevCounts(fields []string) ([]uint64, bool) {
+	counts := []uint64{}
+
+	for _, field := range fields {
+		count, err := strconv.ParseUint(field, 10, 64)
+		if err != nil {
+			size, err := parseHumanSize(field)
+			if err != nil {
+				return nil, false
+			}
+
+			count = uint64(size)
+		}
+
+		counts = append(counts, count)
+	}
+
+	return counts, true
+}
+
+// buildVdevTree builds vdev tree from flat list of vdevs with depths.
+// Returned entries are ones which are not consumed at given depth.
+func buildVdevTree(
+	entries []vdevEntry,
+	depth int,
+) ([]Vdev, []vdevEntry) {
+	var vdevs []Vdev
+
+	for len(entries) > 0 && entries[0].depth == depth {
+		vdev := entries[0].vdev
+		vdev.Children, entries = buildVdevTree(entries[1:], depth+1)
+		vdevs = append(vdevs, vdev)
+	}
+
+	return vdevs, entries
+}
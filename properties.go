@@ -9,11 +9,16 @@ type Properties []Property
 
 // String returns serialized form of list of properties.
 func (properties Properties) String() string {
+	return strings.Join(properties.Pairs(), ",")
+}
+
+// Pairs returns list of properties serialized as `name=value` pairs.
+func (properties Properties) Pairs() []string {
 	pairs := []string{}
 
 	for _, property := range properties {
 		pairs = append(pairs, property.Name+"="+property.Value)
 	}
 
-	return strings.Join(pairs, ",")
+	return pairs
 }
@@ -15,18 +15,27 @@ type Property struct {
 	// Value is a property value. Will be empty only if property is not set.
 	Value string
 
+	// Received is a value which was received via `zfs receive`. It's `-` if
+	// property was not received.
+	Received string
+
 	// Source is a source of where property is coming from.
 	Source Source
 }
 
 // IsReadOnly returns true if property can't be changed (e.g. `used` property).
 func (property Property) IsReadOnly() bool {
-	return property.Source == SourceNone
+	return property.Source.Kind == SourceNone
 }
 
 // IsDefault returns true if property is unchanged and equals default value.
 func (property Property) IsDefault() bool {
-	return property.Source == SourceDefault
+	return property.Source.Kind == SourceDefault
+}
+
+// IsInherited returns true if property value is inherited from parent FS.
+func (property Property) IsInherited() bool {
+	return property.Source.IsInherited()
 }
 
 // IsNone returns true if option is set to none, which is internal zfs value.
@@ -24,4 +24,22 @@ type ReceiveOptions struct {
 
 	// Origin will override target FS `origin` option.
 	Origin string
+
+	// Properties will override properties of received FS, like they were
+	// set locally.
+	Properties Properties
+
+	// ExcludeProperties will exclude properties with specified names from
+	// received FS, so they will be inherited.
+	ExcludeProperties []string
+
+	// DryRun will check stream and report what would be received without
+	// actually receiving anything.
+	DryRun bool
+
+	// SkipHolds will skip receiving of snapshot holds.
+	SkipHolds bool
+
+	// ForceUnmount will force unmount of target FS while receiving.
+	ForceUnmount bool
 }
@@ -0,0 +1,122 @@
+package zfs
+
+import (
+	"io"
+	"sync/atomic"
+	"time"
+)
+
+// receiveProgressInterval is a interval between receive progress reports.
+const receiveProgressInterval = time.Second
+
+// ReceiveWithProgress is a same as Receive(), but callback can be specified to
+// monitor receive progress. Size is a expected stream size, which can be
+// zero if it's not known. Callback can be nil, otherwise it's guaranteed to be
+// called once before receive starts, then each second while stream is read
+// and once after receive successfully completes.
+//
+// Progress is reported using the same SendProgress object as for
+// SendWithProgress(), where Source is a name of receiving FS, SendSize is an
+// expected stream size and Report.Size and Report.Sent are amounts of
+// received bytes. Type, SourceSize, Estimate and Report.Snapshot are not
+// filled, Report.SnapshotIndex is always -1.
+func (zfs *ZFS) ReceiveWithProgress(
+	target string,
+	reader io.Reader,
+	options ReceiveOptions,
+	size int64,
+	callback func(SendProgress),
+) error {
+	if callback == nil {
+		return zfs.Receive(target, reader, options)
+	}
+
+	counter := &countingReader{reader: reader}
+
+	progress := SendProgress{
+		Source:   target,
+		SendSize: size,
+	}
+
+	callback(progress)
+
+	progress.Reported = true
+	progress.Report.SnapshotIndex = -1
+
+	var (
+		done    = make(chan struct{})
+		stopped = make(chan struct{})
+	)
+
+	report := func(now time.Time) {
+		received := counter.count()
+
+		progress.Report.Rate = smoothRate(
+			progress.Report.Rate,
+			received-progress.Report.Size,
+			now.Sub(progress.Report.Timestamp),
+		)
+
+		progress.Report.Time = now.Format("15:04:05")
+		progress.Report.Timestamp = now
+		progress.Report.Size = received
+		progress.Report.Sent = received
+		progress.Report.Percent = getPercent(received, size)
+		progress.Report.Remaining = getRemaining(
+			received,
+			size,
+			progress.Report.Rate,
+		)
+
+		callback(progress)
+	}
+
+	clock := zfs.getClock()
+
+	progress.Report.Timestamp = clock.Now()
+
+	ticks, stop := clock.Ticker(receiveProgressInterval)
+
+	go func() {
+		defer close(stopped)
+		defer stop()
+
+		for {
+			select {
+			case now := <-ticks:
+				report(now)
+			case <-done:
+				return
+			}
+		}
+	}()
+
+	err := zfs.Receive(target, counter, options)
+
+	close(done)
+	<-stopped
+
+	if err == nil {
+		report(clock.Now())
+	}
+
+	return err
+}
+
+// countingReader counts bytes which are read from underlying reader.
+type countingReader struct {
+	reader io.Reader
+	size   int64
+}
+
+func (reader *countingReader) Read(buffer []byte) (int, error) {
+	n, err := reader.reader.Read(buffer)
+
+	atomic.AddInt64(&reader.size, int64(n))
+
+	return n, err
+}
+
+func (reader *countingReader) count() int64 {
+	return atomic.LoadInt64(&reader.size)
+}
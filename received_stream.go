@@ -0,0 +1,118 @@
+package zfs
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// ReceivedStream represents single stream received by `zfs receive -v`.
+type ReceivedStream struct {
+	// Type is a type of stream, which can be full or incremental.
+	Type string
+
+	// Source is a name of snapshot which stream is sent from.
+	Source string
+
+	// Target is a name of snapshot which stream is received into.
+	Target string
+
+	// DryRun is true if stream is not actually received.
+	DryRun bool
+
+	// Size is a received stream size in bytes. It's approximate, because zfs
+	// reports it in human-readable form. Zero for dry run.
+	Size int64
+
+	// Duration is a time which is spent receiving stream. Zero for dry run.
+	Duration time.Duration
+}
+
+// parseReceivedStreams parses `zfs receive -v` output, which looks like:
+//
+//	receiving full stream of zroot/a@1 into backup/a@1
+//	received 1.2M stream in 1 seconds (1.2M/sec)
+func parseReceivedStreams(output string) ([]ReceivedStream, error) {
+	streams := []ReceivedStream{}
+
+	for _, line := range strings.Split(output, "\n") {
+		var (
+			dryRun bool
+			fields = strings.Fields(line)
+		)
+
+		switch {
+		case strings.HasPrefix(line, "receiving "):
+		case strings.HasPrefix(line, "would receive "):
+			dryRun = true
+			fields = fields[1:]
+
+		case strings.HasPrefix(line, "received ") && len(streams) > 0:
+			// received <size> stream in <seconds> seconds (<rate>/sec)
+			if len(fields) < 5 {
+				return nil, fmt.Errorf("unexpected line: '%s'", line)
+			}
+
+			stream := &streams[len(streams)-1]
+
+			size, err := parseHumanSize(fields[1])
+			if err != nil {
+				return nil, err
+			}
+
+			seconds, err := strconv.ParseFloat(fields[4], 64)
+			if err != nil {
+				return nil, err
+			}
+
+			stream.Size = size
+			stream.Duration = time.Duration(seconds * float64(time.Second))
+
+			continue
+
+		default:
+			continue
+		}
+
+		// receiving <type> stream of <source> into <target>
+		if len(fields) != 7 || fields[2] != "stream" || fields[5] != "into" {
+			return nil, fmt.Errorf("unexpected line: '%s'", line)
+		}
+
+		streams = append(streams, ReceivedStream{
+			Type:   fields[1],
+			Source: fields[4],
+			Target: fields[6],
+			DryRun: dryRun,
+		})
+	}
+
+	return streams, nil
+}
+
+// parseHumanSize parses size which is formatted by zfs in human-readable
+// form with binary suffixes, e.g. `1.2M` or `312B`.
+func parseHumanSize(value string) (int64, error) {
+	const suffixes = "BKMGTPE"
+
+	number := strings.TrimSuffix(value, "B")
+	multiplier := float64(1)
+
+	if number != "" {
+		power := strings.IndexByte(suffixes, number[len(number)-1])
+		if power > 0 {
+			number = number[:len(number)-1]
+			for ; power > 0; power-- {
+				multiplier *= 1024
+			}
+		}
+	}
+
+	result, err := strconv.ParseFloat(number, 64)
+	if err != nil {
+		return 0, fmt.Errorf("can't parse size '%s': %s", value, err)
+	}
+
+	return int64(result * multiplier), nil
+}
@@ -0,0 +1,19 @@
+package zfs
+
+// RenameOptions used in conjunction with Rename() method and controls
+// behaviour of rename.
+type RenameOptions struct {
+	// CreateParents will create all non-existing parent FS of new name.
+	CreateParents bool
+
+	// Force will force unmount of any FS that need to be unmounted in the
+	// process.
+	Force bool
+
+	// NotMount will set that renamed FS should not be remounted.
+	NotMount bool
+
+	// Recursive will rename snapshots of all descendent FS. Can be used only
+	// with snapshots.
+	Recursive bool
+}
@@ -0,0 +1,113 @@
+package zfs
+
+import (
+	"context"
+	"io"
+)
+
+// ReplicateOptions used in conjunction with Replicate() function and controls
+// both sides of replication.
+type ReplicateOptions struct {
+	// Send is a options which are used to send source snapshot.
+	Send SendOptions
+
+	// Receive is a options which are used to receive stream on destination.
+	Receive ReceiveOptions
+
+	// Progress is a callback which is used to report send progress the same
+	// way as SendWithProgress() does. Can be nil.
+	Progress func(SendProgress)
+}
+
+// Replicate sends specified snapshot using source zfs handle and receives it
+// into specified FS using destination zfs handle, which can use different
+// runners (e.g. local and remote one). If either side fails, other side is
+// terminated and first occurred error is returned. Both sides are terminated
+// as soon as context of either handle is done.
+func Replicate(
+	source *ZFS,
+	snapshot string,
+	destination *ZFS,
+	target string,
+	options ReplicateOptions,
+) error {
+	ctx, cancel := mergeContexts(
+		source.Runner.Context,
+		destination.Runner.Context,
+	)
+	defer cancel()
+
+	reader, writer := io.Pipe()
+
+	errs := make(chan error, 2)
+
+	// errors are reported before closing pipe, so error of failed side will
+	// be received before error of other side, caused by closed pipe
+	go func() {
+		err := source.WithContext(ctx).SendWithProgress(
+			snapshot,
+			writer,
+			options.Send,
+			options.Progress,
+		)
+
+		errs <- err
+
+		writer.CloseWithError(err)
+	}()
+
+	go func() {
+		err := destination.WithContext(ctx).Receive(
+			target,
+			reader,
+			options.Receive,
+		)
+
+		errs <- err
+
+		reader.CloseWithError(err)
+	}()
+
+	var result error
+	for i := 0; i < 2; i++ {
+		err := <-errs
+		if err != nil && result == nil {
+			result = err
+
+			cancel()
+		}
+	}
+
+	return result
+}
+
+// mergeContexts returns context which is done as soon as either of given
+// contexts (which can be nil) is done or returned cancel function is called.
+// Error of returned context is an error of first context or
+// context.Canceled, if it's done because of second one.
+func mergeContexts(
+	first context.Context,
+	second context.Context,
+) (context.Context, context.CancelFunc) {
+	if first == nil {
+		first, second = second, nil
+	}
+
+	if first == nil {
+		first = context.Background()
+	}
+
+	ctx, cancel := context.WithCancel(first)
+
+	if second != nil {
+		go func() {
+			select {
+			case <-second.Done():
+				cancel()
+			case <-ctx.Done():
+			}
+		}()
+	}
+
+	return ctx, cancel
+}
@@ -0,0 +1,95 @@
+package zfs
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/kovetskiy/runcmd"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestReplicate_ProperlyCallsBinaries(t *testing.T) {
+	test := assert.New(t)
+
+	source, err := NewZFS()
+	test.NoError(err)
+
+	destination, err := NewZFS()
+	test.NoError(err)
+
+	expectCommand(test, source, "zfs", "send", "-i", "a@1", "a@2")
+	expectCommand(test, destination, "zfs", "receive", "-F", "backup/a")
+
+	err = Replicate(
+		source, "a@2",
+		destination, "backup/a",
+		ReplicateOptions{
+			Send:    SendOptions{Incremental: "a@1"},
+			Receive: ReceiveOptions{ForceRollback: true},
+		},
+	)
+	test.NoError(err)
+}
+
+func TestReplicate_ReturnsReceiveErrorAndKillsSend(t *testing.T) {
+	test := assert.New(t)
+
+	source, err := NewZFS()
+	test.NoError(err)
+
+	// send will never end unless it's killed (or not started at all)
+	source.SetRunner(&blockingRunner{})
+
+	destination, err := NewZFS()
+	test.NoError(err)
+
+	destination.SetRunner(&runcmd.MockRunner{
+		Stderr: []byte("cannot receive: dataset does not exist"),
+	})
+
+	err = Replicate(
+		source, "a@2",
+		destination, "backup/a",
+		ReplicateOptions{},
+	)
+	test.Error(err)
+	test.True(errors.Is(err, ErrDatasetNotFound))
+}
+
+func TestReplicate_StopsWhenDestinationContextIsDone(t *testing.T) {
+	test := assert.New(t)
+
+	source, err := NewZFS()
+	test.NoError(err)
+
+	source.SetRunner(&blockingRunner{})
+
+	destination, err := NewZFS()
+	test.NoError(err)
+
+	destination.SetRunner(&blockingRunner{})
+
+	ctx, cancel := context.WithCancel(context.Background())
+
+	done := make(chan error)
+	go func() {
+		done <- Replicate(
+			source, "a@2",
+			destination.WithContext(ctx), "backup/a",
+			ReplicateOptions{},
+		)
+	}()
+
+	cancel()
+
+	select {
+	case err := <-done:
+		test.IsType(ErrCanceled{}, err)
+		test.True(errors.Is(err, context.Canceled))
+
+	case <-time.After(5 * time.Second):
+		test.Fail("replication is not terminated")
+	}
+}
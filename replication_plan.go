@@ -0,0 +1,216 @@
+package zfs
+
+import (
+	"errors"
+	"strings"
+)
+
+// PlanOptions used in conjunction with PlanReplication() function.
+type PlanOptions struct {
+	// IncludeIntermediary will plan to send all intermediary snapshots
+	// between common and latest snapshots (`-I`), instead of sending only
+	// difference between them (`-i`).
+	IncludeIntermediary bool
+}
+
+// ReplicationPlan describes how source FS should be sent to make destination
+// FS up to date. It can be printed as dry run or executed.
+type ReplicationPlan struct {
+	// Source is a source FS name.
+	Source string
+
+	// Destination is a destination FS name.
+	Destination string
+
+	// Base is a full name of newest snapshot which exists on both sides. It's
+	// empty if destination FS does not exist and full stream should be sent.
+	Base string
+
+	// Snapshot is a full name of latest source snapshot which should be sent.
+	Snapshot string
+
+	// Snapshots is a list of full names of source snapshots, which will be
+	// received by destination.
+	Snapshots []string
+
+	// IncludeIntermediary is true if intermediary snapshots should be sent
+	// (`-I` flag).
+	IncludeIntermediary bool
+
+	source      *ZFS
+	destination *ZFS
+}
+
+// PlanReplication compares snapshots of source and destination FS, which can
+// be reached by different zfs handles, and returns plan for sending source
+// FS to destination. Newest common snapshot is found by both name and guid.
+// ErrReplicationDiverged is returned if destination has snapshots newer than
+// common one, and ErrNoCommonSnapshot is returned if there are no common
+// snapshots at all.
+func PlanReplication(
+	source *ZFS,
+	sourceFS string,
+	destination *ZFS,
+	destinationFS string,
+	options PlanOptions,
+) (ReplicationPlan, error) {
+	plan := ReplicationPlan{
+		Source:      sourceFS,
+		Destination: destinationFS,
+		source:      source,
+		destination: destination,
+	}
+
+	sourceSnapshots, err := source.listSnapshots(sourceFS)
+	if err != nil {
+		return plan, err
+	}
+
+	if len(sourceSnapshots) == 0 {
+		return plan, ErrNoSnapshots{Name: sourceFS}
+	}
+
+	plan.Snapshot = sourceSnapshots[len(sourceSnapshots)-1].Name
+
+	destinationSnapshots, err := destination.listSnapshots(destinationFS)
+	switch {
+	case errors.Is(err, ErrDatasetNotFound):
+		// destination does not exist, so latest snapshot will be sent as
+		// full stream
+		plan.Snapshots = []string{plan.Snapshot}
+
+		return plan, nil
+
+	case err != nil:
+		return plan, err
+	}
+
+	guids := map[string]string{}
+	for _, snapshot := range sourceSnapshots {
+		name := getSnapshotName(snapshot.Name)
+		guids[name] = snapshot.GetProperty("guid").Value
+	}
+
+	base := -1
+	for i := len(destinationSnapshots) - 1; i >= 0; i-- {
+		snapshot := destinationSnapshots[i]
+
+		guid, ok := guids[getSnapshotName(snapshot.Name)]
+		if ok && guid == snapshot.GetProperty("guid").Value {
+			base = i
+			break
+		}
+	}
+
+	if base < 0 {
+		return plan, ErrNoCommonSnapshot{
+			Source:      sourceFS,
+			Destination: destinationFS,
+		}
+	}
+
+	if base < len(destinationSnapshots)-1 {
+		diverged := []string{}
+		for _, snapshot := range destinationSnapshots[base+1:] {
+			diverged = append(diverged, snapshot.Name)
+		}
+
+		return plan, ErrReplicationDiverged{
+			Destination: destinationFS,
+			Snapshots:   diverged,
+		}
+	}
+
+	plan.Base = sourceFS + "@" +
+		getSnapshotName(destinationSnapshots[base].Name)
+
+	for i, snapshot := range sourceSnapshots {
+		if snapshot.Name != plan.Base {
+			continue
+		}
+
+		for _, snapshot := range sourceSnapshots[i+1:] {
+			plan.Snapshots = append(plan.Snapshots, snapshot.Name)
+		}
+	}
+
+	if len(plan.Snapshots) > 1 && options.IncludeIntermediary {
+		plan.IncludeIntermediary = true
+	}
+
+	if len(plan.Snapshots) > 1 && !options.IncludeIntermediary {
+		plan.Snapshots = []string{plan.Snapshot}
+	}
+
+	return plan, nil
+}
+
+// IsUpToDate returns true if destination already has latest source snapshot.
+func (plan ReplicationPlan) IsUpToDate() bool {
+	return len(plan.Snapshots) == 0
+}
+
+// SendOptions returns send options which correspond to given plan.
+func (plan ReplicationPlan) SendOptions() SendOptions {
+	return SendOptions{
+		Incremental:         plan.Base,
+		IncludeIntermediary: plan.IncludeIntermediary,
+	}
+}
+
+// Execute replicates source FS to destination according to plan. Send
+// options related to incremental stream are overridden by plan. Nothing is
+// done if destination is already up to date.
+func (plan ReplicationPlan) Execute(options ReplicateOptions) error {
+	if plan.IsUpToDate() {
+		return nil
+	}
+
+	options.Send.Incremental = plan.Base
+	options.Send.IncludeIntermediary = plan.IncludeIntermediary
+
+	return Replicate(
+		plan.source,
+		plan.Snapshot,
+		plan.destination,
+		plan.Destination,
+		options,
+	)
+}
+
+// String returns dry run representation of plan as shell pipeline.
+func (plan ReplicationPlan) String() string {
+	if plan.IsUpToDate() {
+		return "# " + plan.Destination + " is up to date"
+	}
+
+	send := []string{"zfs", "send"}
+
+	if plan.Base != "" {
+		if plan.IncludeIntermediary {
+			send = append(send, "-I", plan.Base)
+		} else {
+			send = append(send, "-i", plan.Base)
+		}
+	}
+
+	send = append(send, plan.Snapshot)
+
+	return strings.Join(send, " ") + " | zfs receive " + plan.Destination
+}
+
+// listSnapshots returns snapshots of given FS with guid property, sorted by
+// creation order.
+func (zfs *ZFS) listSnapshots(target string) ([]FS, error) {
+	return zfs.ListWithOptions(target, ListOptions{
+		Depth:      1,
+		Types:      []Type{TypeSnapshot},
+		Properties: []string{"guid"},
+		Sort:       []ListSort{{Property: "createtxg"}},
+	})
+}
+
+// getSnapshotName returns snapshot name without FS name.
+func getSnapshotName(snapshot string) string {
+	return snapshot[strings.Index(snapshot, "@")+1:]
+}
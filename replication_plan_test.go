@@ -0,0 +1,140 @@
+package zfs
+
+import (
+	"testing"
+
+	"github.com/kovetskiy/runcmd"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestPlanReplication_FindsCommonSnapshotByGUID(t *testing.T) {
+	test := assert.New(t)
+
+	source, destination := getPlanTestHandles(
+		test,
+		[]string{
+			"a@1\tguid\t100\t-\t-",
+			"a@2\tguid\t200\t-\t-",
+			"a@3\tguid\t300\t-\t-",
+			"a@4\tguid\t400\t-\t-",
+		},
+		[]string{
+			"b/a@1\tguid\t100\t-\t-",
+			"b/a@2\tguid\t200\t-\t-",
+		},
+	)
+
+	plan, err := PlanReplication(source, "a", destination, "b/a", PlanOptions{})
+	test.NoError(err)
+	test.Equal("a@2", plan.Base)
+	test.Equal("a@4", plan.Snapshot)
+	test.Equal([]string{"a@4"}, plan.Snapshots)
+	test.False(plan.IsUpToDate())
+	test.Equal("zfs send -i a@2 a@4 | zfs receive b/a", plan.String())
+
+	plan, err = PlanReplication(
+		source, "a",
+		destination, "b/a",
+		PlanOptions{IncludeIntermediary: true},
+	)
+	test.NoError(err)
+	test.Equal([]string{"a@3", "a@4"}, plan.Snapshots)
+	test.Equal(
+		SendOptions{Incremental: "a@2", IncludeIntermediary: true},
+		plan.SendOptions(),
+	)
+	test.Equal("zfs send -I a@2 a@4 | zfs receive b/a", plan.String())
+}
+
+func TestPlanReplication_ReturnsUpToDatePlan(t *testing.T) {
+	test := assert.New(t)
+
+	source, destination := getPlanTestHandles(
+		test,
+		[]string{"a@1\tguid\t100\t-\t-"},
+		[]string{"b/a@1\tguid\t100\t-\t-"},
+	)
+
+	plan, err := PlanReplication(source, "a", destination, "b/a", PlanOptions{})
+	test.NoError(err)
+	test.True(plan.IsUpToDate())
+	test.NoError(plan.Execute(ReplicateOptions{}))
+}
+
+func TestPlanReplication_DetectsDivergence(t *testing.T) {
+	test := assert.New(t)
+
+	source, destination := getPlanTestHandles(
+		test,
+		[]string{
+			"a@1\tguid\t100\t-\t-",
+			"a@2\tguid\t200\t-\t-",
+		},
+		[]string{
+			"b/a@1\tguid\t100\t-\t-",
+			"b/a@2\tguid\t999\t-\t-",
+			"b/a@x\tguid\t500\t-\t-",
+		},
+	)
+
+	_, err := PlanReplication(source, "a", destination, "b/a", PlanOptions{})
+	test.Equal(
+		ErrReplicationDiverged{
+			Destination: "b/a",
+			Snapshots:   []string{"b/a@2", "b/a@x"},
+		},
+		err,
+	)
+}
+
+func TestPlanReplication_ReturnsErrorIfNoCommonSnapshot(t *testing.T) {
+	test := assert.New(t)
+
+	source, destination := getPlanTestHandles(
+		test,
+		[]string{"a@1\tguid\t100\t-\t-"},
+		[]string{"b/a@1\tguid\t200\t-\t-"},
+	)
+
+	_, err := PlanReplication(source, "a", destination, "b/a", PlanOptions{})
+	test.Equal(ErrNoCommonSnapshot{Source: "a", Destination: "b/a"}, err)
+}
+
+func TestPlanReplication_PlansFullSendIfDestinationNotExists(t *testing.T) {
+	test := assert.New(t)
+
+	source, destination := getPlanTestHandles(
+		test,
+		[]string{"a@1\tguid\t100\t-\t-", "a@2\tguid\t200\t-\t-"},
+		nil,
+	)
+
+	destination.SetRunner(&runcmd.MockRunner{
+		Stderr: []byte("cannot open 'b/a': dataset does not exist"),
+	})
+
+	plan, err := PlanReplication(source, "a", destination, "b/a", PlanOptions{})
+	test.NoError(err)
+	test.Equal("", plan.Base)
+	test.Equal("zfs send a@2 | zfs receive b/a", plan.String())
+}
+
+func getPlanTestHandles(
+	test *assert.Assertions,
+	sourceOutput []string,
+	destinationOutput []string,
+) (*ZFS, *ZFS) {
+	source, err := NewZFS()
+	test.NoError(err)
+
+	source.SetRunner(&runcmd.MockRunner{Stdout: asBytes(sourceOutput...)})
+
+	destination, err := NewZFS()
+	test.NoError(err)
+
+	destination.SetRunner(
+		&runcmd.MockRunner{Stdout: asBytes(destinationOutput...)},
+	)
+
+	return source, destination
+}
@@ -1,9 +1,15 @@
 package zfs
 
-import "github.com/kovetskiy/runcmd"
-import "github.com/reconquest/lexec-go"
+import (
+	"context"
 
-// Runner represents object which will be used to execute zfs binaries.
+	"github.com/kovetskiy/runcmd"
+	"github.com/reconquest/lexec-go"
+)
+
+// Runner represents object which will be used to execute zfs binaries. Runner
+// is not modified by Command() (unless deprecated Sudo flag is set), so it
+// can be used from several goroutines at once.
 type Runner struct {
 	// runcmd.Runner is a underlying remote, local or test mock runner.
 	runcmd.Runner
@@ -12,26 +18,124 @@ type Runner struct {
 	// and stderr.
 	Logger lexec.Logger
 
-	// Binary specifies zfs binary name (`zfs` by default).
+	// Binary specifies binary name, `zfs` for ZFS and `zpool` for Zpool
+	// handles.
 	Binary string
 
 	// Sudo is a flag which controls that next execution will be run under
-	// privileged rights.
+	// privileged rights. It's reset by next execution, so it can't be used
+	// from several goroutines at once.
+	//
+	// Deprecated: use Escalator instead.
 	Sudo bool
+
+	// Escalator is used to run every execution under privileged rights. If
+	// nil, executions will be run with current rights.
+	Escalator *Escalator
+
+	// Context is a context which will be bound to every command. Commands
+	// will be terminated when context is done, if runner workers implement
+	// KillableWorker (like LocalRunner ones do). Can be nil.
+	Context context.Context
+
+	// clock is used to time progress reports. System clock is used if nil.
+	clock clock
+}
+
+// WithSudo returns copy of runner which will run all commands with
+// privileged rights using sudo.
+func (runner *Runner) WithSudo() *Runner {
+	return runner.WithEscalator(EscalatorSudo)
+}
+
+// WithEscalator returns copy of runner which will run all commands with
+// privileged rights using given escalator.
+func (runner *Runner) WithEscalator(escalator Escalator) *Runner {
+	copied := *runner
+	copied.Escalator = &escalator
+
+	return &copied
+}
+
+// WithContext returns copy of runner which will run all commands with given
+// context, so they will be terminated as soon as context is done.
+func (runner *Runner) WithContext(ctx context.Context) *Runner {
+	copied := *runner
+	copied.Context = ctx
+
+	return &copied
+}
+
+// SetRunner sets underlying runner which will be used to execute binary.
+func (runner *Runner) SetRunner(underlying runcmd.Runner) *Runner {
+	runner.Runner = underlying
+
+	return runner
+}
+
+// SetLogger sets logger which will be used to log binary starts, exit codes
+// as well as stdout/stderr.
+func (runner *Runner) SetLogger(logger lexec.Logger) *Runner {
+	runner.Logger = logger
+
+	return runner
 }
 
 // Command returns object which is suitable for later execution. Binary name
-// is prepended automatically, so args should not contain `zfs`.
+// is prepended automatically, so args should not contain `zfs` or `zpool`.
 func (runner *Runner) Command(args ...string) Command {
+	return runner.CommandContext(runner.Context, args...)
+}
+
+// CommandContext is a same as Command(), but returned command will be
+// terminated when given context is done.
+func (runner *Runner) CommandContext(
+	ctx context.Context,
+	args ...string,
+) Command {
 	name := runner.Binary
+	interactive := false
+
+	if runner.Escalator != nil {
+		name, args = runner.Escalator.Wrap(name, args)
+		interactive = runner.Escalator.Interactive
+	}
 
 	if runner.Sudo {
-		args = append([]string{name}, args...)
-		name = "sudo"
+		name, args = EscalatorSudo.Wrap(name, args)
+		interactive = true
 		runner.Sudo = false
 	}
 
+	worker := runner.worker(interactive, name, args...)
+
 	return Command{
-		lexec.New(runner.Logger, runner.Runner.Command(name, args...)),
+		Execution: lexec.New(runner.Logger, worker),
+		context:   ctx,
+		worker:    worker,
 	}
 }
+
+// worker returns worker of underlying runner. Commands of interactive
+// escalators are started by LocalRunner in foreground process group, so
+// escalator is able to ask password.
+func (runner *Runner) worker(
+	interactive bool,
+	name string,
+	args ...string,
+) runcmd.CmdWorker {
+	if local, ok := runner.Runner.(*LocalRunner); ok && interactive {
+		return local.foregroundCommand(name, args...)
+	}
+
+	return runner.Runner.Command(name, args...)
+}
+
+// getClock returns clock which should be used to time progress reports.
+func (runner *Runner) getClock() clock {
+	if runner.clock == nil {
+		return systemClock{}
+	}
+
+	return runner.clock
+}
@@ -0,0 +1,321 @@
+package zfs
+
+import (
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// ScanFunction is a kind of pool scan.
+type ScanFunction string
+
+const (
+	// ScanScrub is a scrub, which verifies all pool data.
+	ScanScrub ScanFunction = "scrub"
+
+	// ScanResilver is a resilver, which rebuilds data on replaced devices.
+	ScanResilver ScanFunction = "resilver"
+)
+
+// ScanState is a state of pool scan.
+type ScanState string
+
+const (
+	// ScanNone means that scan never has been requested.
+	ScanNone ScanState = "none"
+
+	// ScanScanning means that scan is in progress.
+	ScanScanning ScanState = "scanning"
+
+	// ScanPaused means that scrub is paused and can be resumed.
+	ScanPaused ScanState = "paused"
+
+	// ScanFinished means that scan is completed.
+	ScanFinished ScanState = "finished"
+
+	// ScanCanceled means that scan is stopped before completion.
+	ScanCanceled ScanState = "canceled"
+)
+
+// ScanProgress represents state of current or last scrub or resilver, as
+// reported by `zpool status`.
+type ScanProgress struct {
+	// Function is a kind of scan, empty if scan never has been requested.
+	Function ScanFunction
+
+	// State is a state of scan.
+	State ScanState
+
+	// Time is a time when scan is started, paused, finished or canceled,
+	// depending on State.
+	Time time.Time
+
+	// Scanned is an amount of data which is read to find blocks to verify.
+	Scanned Size
+
+	// Issued is an amount of data which is actually verified.
+	Issued Size
+
+	// Total is a total amount of data to scan.
+	Total Size
+
+	// ScanRate is a rate of scanning in bytes per second.
+	ScanRate Size
+
+	// IssueRate is a rate of verification in bytes per second.
+	IssueRate Size
+
+	// Repaired is an amount of repaired (or resilvered) data.
+	Repaired Size
+
+	// Percent is a completion percentage.
+	Percent float64
+
+	// Remaining is an estimated time to completion, zero if unknown.
+	Remaining time.Duration
+
+	// Duration is a time which is taken by finished scan.
+	Duration time.Duration
+
+	// Errors is an amount of errors which are found by finished scan.
+	Errors uint64
+}
+
+// scanTimeLayout is a layout of times printed by `zpool status`, which is
+// ctime(3) format with space-padding collapsed.
+const scanTimeLayout = "Mon Jan 2 15:04:05 2006"
+
+var (
+	scanInProgress = regexp.MustCompile(
+		`^(scrub|resilver) in progress since (.+)$`,
+	)
+	scanPaused = regexp.MustCompile(
+		`^(scrub) paused since (.+)$`,
+	)
+	scanCanceled = regexp.MustCompile(
+		`^(scrub|resilver) canceled on (.+)$`,
+	)
+	scanFinished = regexp.MustCompile(
+		`^(scrub repaired|resilvered) (\S+) in (.+) with (\d+) errors on (.+)$`,
+	)
+
+	scanScanned = regexp.MustCompile(
+		`(\S+) (?:/ (\S+) )?scanned(?: out of (\S+))?(?: at (\S+)/s)?`,
+	)
+	scanIssued = regexp.MustCompile(
+		`(\S+) (?:/ (\S+) )?issued(?: at (\S+)/s)?`,
+	)
+	scanTotal    = regexp.MustCompile(`(\S+) total`)
+	scanRepaired = regexp.MustCompile(`(\S+) (?:repaired|resilvered),`)
+	scanPercent  = regexp.MustCompile(`([\d.]+)% done`)
+	scanETA      = regexp.MustCompile(`, ([^,]+) to go`)
+
+	scanDuration = regexp.MustCompile(`^(?:(\d+) days? )?(\d+):(\d+):(\d+)$`)
+)
+
+// ScanProgress returns typed progress of scrub or resilver, which is parsed
+// from Scan text.
+func (status PoolStatus) ScanProgress() (ScanProgress, error) {
+	return parseScanProgress(status.Scan)
+}
+
+// parseScanProgress parses `scan:` section of `zpool status`.
+func parseScanProgress(text string) (ScanProgress, error) {
+	lines := strings.Split(text, "\n")
+	header := strings.Join(strings.Fields(lines[0]), " ")
+
+	var (
+		progress = ScanProgress{}
+		when     string
+	)
+
+	if header == "" || header == "none requested" {
+		progress.State = ScanNone
+
+		return progress, nil
+	}
+
+	if matches := scanFinished.FindStringSubmatch(header); matches != nil {
+		progress.Function = ScanScrub
+		if matches[1] == "resilvered" {
+			progress.Function = ScanResilver
+		}
+
+		progress.State = ScanFinished
+		progress.Percent = 100
+		when = matches[5]
+
+		repaired, err := parseHumanSize(matches[2])
+		if err != nil {
+			return ScanProgress{}, err
+		}
+
+		progress.Repaired = Size(repaired)
+
+		progress.Duration, err = parseScanDuration(matches[3])
+		if err != nil {
+			return ScanProgress{}, err
+		}
+
+		progress.Errors, err = strconv.ParseUint(matches[4], 10, 64)
+		if err != nil {
+			return ScanProgress{}, err
+		}
+	} else {
+		states := []struct {
+			expression *regexp.Regexp
+			state      ScanState
+		}{
+			{scanInProgress, ScanScanning},
+			{scanPaused, ScanPaused},
+			{scanCanceled, ScanCanceled},
+		}
+
+		for _, state := range states {
+			matches := state.expression.FindStringSubmatch(header)
+			if matches != nil {
+				progress.Function = ScanFunction(matches[1])
+				progress.State = state.state
+				when = matches[2]
+				break
+			}
+		}
+
+		if progress.State == "" {
+			return ScanProgress{}, fmt.Errorf(
+				"unexpected scan status: %q", header,
+			)
+		}
+	}
+
+	var err error
+
+	progress.Time, err = parseStatusTime(when)
+	if err != nil {
+		return ScanProgress{}, err
+	}
+
+	if progress.State == ScanScanning || progress.State == ScanPaused {
+		err = parseScanCounters(
+			strings.Join(lines[1:], ", "),
+			&progress,
+		)
+		if err != nil {
+			return ScanProgress{}, err
+		}
+	}
+
+	return progress, nil
+}
+
+// parseScanCounters parses amounts, rates and completion of running scan.
+func parseScanCounters(text string, progress *ScanProgress) error {
+	sizes := []struct {
+		expression *regexp.Regexp
+		targets    []*Size
+	}{
+		{
+			scanScanned,
+			[]*Size{
+				&progress.Scanned,
+				&progress.Total,
+				&progress.Total,
+				&progress.ScanRate,
+			},
+		},
+		{
+			scanIssued,
+			[]*Size{&progress.Issued, &progress.Total, &progress.IssueRate},
+		},
+		{scanTotal, []*Size{&progress.Total}},
+		{scanRepaired, []*Size{&progress.Repaired}},
+	}
+
+	for _, size := range sizes {
+		matches := size.expression.FindStringSubmatch(text)
+		if matches == nil {
+			continue
+		}
+
+		for i, target := range size.targets {
+			if matches[i+1] == "" {
+				continue
+			}
+
+			value, err := parseHumanSize(matches[i+1])
+			if err != nil {
+				return err
+			}
+
+			*target = Size(value)
+		}
+	}
+
+	if matches := scanPercent.FindStringSubmatch(text); matches != nil {
+		percent, err := strconv.ParseFloat(matches[1], 64)
+		if err != nil {
+			return fmt.Errorf("invalid scan percentage: %q", matches[1])
+		}
+
+		progress.Percent = percent
+	}
+
+	if matches := scanETA.FindStringSubmatch(text); matches != nil {
+		remaining, err := parseScanDuration(matches[1])
+		if err != nil {
+			return err
+		}
+
+		progress.Remaining = remaining
+	}
+
+	return nil
+}
+
+// parseScanDuration parses durations like `1 days 02:03:04` or `00:03:04`
+// as well as legacy `0h3m` form.
+func parseScanDuration(value string) (time.Duration, error) {
+	matches := scanDuration.FindStringSubmatch(value)
+	if matches == nil {
+		duration, err := time.ParseDuration(value)
+		if err != nil {
+			return 0, fmt.Errorf("invalid scan duration: %q", value)
+		}
+
+		return duration, nil
+	}
+
+	var duration time.Duration
+
+	units := []time.Duration{
+		24 * time.Hour,
+		time.Hour,
+		time.Minute,
+		time.Second,
+	}
+	for i, unit := range units {
+		if matches[i+1] == "" {
+			continue
+		}
+
+		amount, err := strconv.Atoi(matches[i+1])
+		if err != nil {
+			return 0, fmt.Errorf("invalid scan duration: %q", value)
+		}
+
+		duration += time.Duration(amount) * unit
+	}
+
+	return duration, nil
+}
+
+// parseStatusTime parses ctime(3)-formatted time printed by `zpool status`.
+func parseStatusTime(value string) (time.Time, error) {
+	return time.ParseInLocation(
+		scanTimeLayout,
+		strings.Join(strings.Fields(value), " "),
+		time.Local,
+	)
+}
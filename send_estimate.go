@@ -0,0 +1,116 @@
+package zfs
+
+import (
+	"bufio"
+	"io"
+	"strconv"
+	"strings"
+)
+
+// SendEstimate represents estimation of send stream, which is reported by
+// `zfs send -v -P`.
+type SendEstimate struct {
+	// Type is a type of first sent stream, which can be full or incremental.
+	Type string
+
+	// Snapshots is a list of snapshot streams which will be sent. It contains
+	// several items for `-I` or `-R` sends.
+	Snapshots []SendEstimateSnapshot
+
+	// Size is a total estimated size of all streams in bytes.
+	Size int64
+}
+
+// SendEstimateSnapshot represents estimation of single snapshot stream.
+type SendEstimateSnapshot struct {
+	// Type is a type of stream, which can be full or incremental.
+	Type string
+
+	// From is a base snapshot of incremental stream. Empty for full stream.
+	From string
+
+	// Snapshot is a name of sent snapshot.
+	Snapshot string
+
+	// Size is a estimated size of stream in bytes.
+	Size int64
+}
+
+// readSendEstimate reads send estimation which is printed by `zfs send -v -P`
+// before progress reports, ending with `size` line. Any preceding lines
+// which are not estimation (e.g. resume token contents for `zfs send -t`)
+// are skipped.
+func readSendEstimate(reader *bufio.Reader) (SendEstimate, error) {
+	estimate := SendEstimate{}
+
+	for {
+		line, err := reader.ReadString('\n')
+		if err != nil && err != io.EOF {
+			return estimate, err
+		}
+
+		fields := strings.Split(strings.TrimRight(line, "\r\n"), "\t")
+
+		switch {
+		case fields[0] == "full" && len(fields) == 3:
+			size, err := strconv.ParseInt(fields[2], 10, 64)
+			if err != nil {
+				return estimate, err
+			}
+
+			estimate.Snapshots = append(
+				estimate.Snapshots,
+				SendEstimateSnapshot{
+					Type:     fields[0],
+					Snapshot: fields[1],
+					Size:     size,
+				},
+			)
+
+		case fields[0] == "incremental" && len(fields) == 4:
+			size, err := strconv.ParseInt(fields[3], 10, 64)
+			if err != nil {
+				return estimate, err
+			}
+
+			estimate.Snapshots = append(
+				estimate.Snapshots,
+				SendEstimateSnapshot{
+					Type:     fields[0],
+					From:     fields[1],
+					Snapshot: fields[2],
+					Size:     size,
+				},
+			)
+
+		case fields[0] == "size" && len(fields) == 2:
+			estimate.Size, err = strconv.ParseInt(fields[1], 10, 64)
+			if err != nil {
+				return estimate, err
+			}
+
+			return estimate.complete()
+		}
+
+		if err == io.EOF {
+			return estimate.complete()
+		}
+	}
+}
+
+// complete fills estimation fields which are derived from snapshot streams.
+func (estimate SendEstimate) complete() (SendEstimate, error) {
+	if len(estimate.Snapshots) == 0 {
+		return estimate, io.ErrUnexpectedEOF
+	}
+
+	estimate.Type = estimate.Snapshots[0].Type
+
+	if estimate.Size == 0 {
+		for _, snapshot := range estimate.Snapshots {
+			estimate.Size += snapshot.Size
+		}
+	}
+
+	return estimate, nil
+}
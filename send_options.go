@@ -1,10 +1,13 @@
 package zfs
 
+import "strings"
+
 // SendOptions represents options which can alter Send() process.
 type SendOptions struct {
-	// Incremental can be used to specify base snapshot name which will be
-	// used to create incremental data stream instead of sending full data
-	// stream.
+	// Incremental can be used to specify base snapshot or bookmark name
+	// which will be used to create incremental data stream instead of sending
+	// full data stream. Base should belong to the same FS as sent snapshot;
+	// short form like `@snapshot` or `#bookmark` can be used as well.
 	Incremental string
 
 	// IncludeIntermediary will tells Send() that it should send all
@@ -35,4 +38,109 @@ type SendOptions struct {
 
 	// IncludeProperties will include dataset's properties in the stream.
 	IncludeProperties bool
+
+	// Raw will send data exactly as it exists on disk, so encrypted data
+	// will be sent without decryption and keys will never leave source host.
+	// Can't be used together with Compressed.
+	Raw bool
+
+	// Holds will include user holds of snapshots in the stream.
+	Holds bool
+
+	// BackupProperties will send only received property values, regardless
+	// of local overrides. Can be used to restore received properties backed
+	// up on the sent FS.
+	BackupProperties bool
+
+	// Deduplicated will generate deduplicated stream. It's deprecated and
+	// ignored by modern zfs versions.
+	Deduplicated bool
+
+	// Saved will send partially received snapshot which was saved by
+	// resumable receive. Can't be used together with Incremental or
+	// ReplicationStream.
+	Saved bool
+
+	// ProcessTitle will set process title to show send progress.
+	ProcessTitle bool
+
+	// Redact will generate redacted stream using specified redaction
+	// bookmark. Can't be used together with ReplicationStream.
+	Redact string
+}
+
+// Validate returns ErrIncompatibleSendOptions if options can't be used
+// together.
+func (options SendOptions) Validate() error {
+	conflicts := []struct {
+		first  bool
+		second bool
+		flags  [2]string
+	}{
+		{
+			options.Raw, options.Compressed,
+			[2]string{"-w", "-c"},
+		},
+		{
+			options.Saved, options.Incremental != "",
+			[2]string{"--saved", "-i"},
+		},
+		{
+			options.Saved, options.ReplicationStream,
+			[2]string{"--saved", "-R"},
+		},
+		{
+			options.Redact != "", options.ReplicationStream,
+			[2]string{"--redact", "-R"},
+		},
+	}
+
+	for _, conflict := range conflicts {
+		if conflict.first && conflict.second {
+			return ErrIncompatibleSendOptions{
+				First:  conflict.flags[0],
+				Second: conflict.flags[1],
+			}
+		}
+	}
+
+	return nil
+}
+
+// validate is a same as Validate(), but also checks that incremental base
+// is suitable for specified sent snapshot.
+func (options SendOptions) validate(source string) error {
+	err := options.Validate()
+	if err != nil {
+		return err
+	}
+
+	base := options.Incremental
+	if base == "" {
+		return nil
+	}
+
+	if !strings.ContainsAny(base, "@#") {
+		return ErrInvalidIncrementalBase{
+			Base:   base,
+			Reason: "base is not a snapshot or bookmark",
+		}
+	}
+
+	if strings.Contains(base, "#") && options.IncludeIntermediary {
+		return ErrInvalidIncrementalBase{
+			Base:   base,
+			Reason: "intermediary snapshots can't be sent from bookmark",
+		}
+	}
+
+	dataset := getDatasetName(base)
+	if dataset != "" && dataset != getDatasetName(source) {
+		return ErrInvalidIncrementalBase{
+			Base:   base,
+			Reason: "base does not belong to " + getDatasetName(source),
+		}
+	}
+
+	return nil
 }
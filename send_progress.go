@@ -1,7 +1,14 @@
 package zfs
 
+import "time"
+
+// rateSmoothing is a weight of the latest measured rate in the smoothed send
+// or receive rate.
+const rateSmoothing = 0.3
+
 // SendProgress represents progress object which will be reported using
-// SendWithProgress() method.
+// SendWithProgress() method. ReceiveWithProgress() reports progress of the
+// same shape, see it's description for details.
 type SendProgress struct {
 	// Type is a type of send, which can be full, incremental, etc.
 	Type string
@@ -16,6 +23,10 @@ type SendProgress struct {
 	// sent.
 	SendSize int64
 
+	// Estimate is a complete send estimation, including estimations of
+	// every snapshot stream for `-I` and `-R` sends.
+	Estimate SendEstimate
+
 	// Reported is true when there is available data about already sent stream.
 	// It will be false on the first progress report and true on all further
 	// progress reports.
@@ -30,15 +41,188 @@ type SendProgress struct {
 		// local time from source host).
 		Time string
 
-		// Size is a size in bytes describing how many bytes of estimated size
-		// is sent.
+		// Timestamp is a Time parsed as today's local time. Midnight wraps
+		// are handled, so timestamps are increasing across reports.
+		Timestamp time.Time
+
+		// Size is a size in bytes describing how many bytes of currently
+		// transferring snapshot is sent.
 		Size int64
 
 		// Snapshot is a currently transferring snapshot name.
 		Snapshot string
+
+		// SnapshotIndex is a index of currently transferring snapshot in
+		// Estimate.Snapshots or -1 if it's not found there.
+		SnapshotIndex int
+
+		// SnapshotChanged is true if transfer of next snapshot is started
+		// since previous report.
+		SnapshotChanged bool
+
+		// Sent is a total size in bytes of all sent snapshots.
+		Sent int64
+
+		// Rate is a smoothed send rate in bytes per second.
+		Rate float64
+
+		// Percent is a percent of SendSize which is already sent.
+		Percent float64
+
+		// Remaining is a estimated duration until send is complete. It's zero
+		// if rate is not known yet.
+		Remaining time.Duration
 	}
 
 	// Error is a any error which occured during obtaining progress report (not
 	// send itself).
 	Error error
 }
+
+// sendProgressTracker computes progress report fields which depend on
+// previous reports.
+type sendProgressTracker struct {
+	// completed is a total size of snapshots which are already sent.
+	completed int64
+	size      int64
+	snapshot  string
+	index     int
+	timestamp time.Time
+	rate      float64
+}
+
+// track fills derived report fields of given progress, which should contain
+// raw report values.
+func (tracker *sendProgressTracker) track(progress *SendProgress) {
+	report := &progress.Report
+
+	previous := tracker.completed + tracker.size
+
+	report.Timestamp = tracker.parseTime(report.Time)
+
+	report.SnapshotChanged = tracker.snapshot != "" &&
+		report.Snapshot != tracker.snapshot
+
+	report.SnapshotIndex = -1
+	for i, snapshot := range progress.Estimate.Snapshots {
+		if snapshot.Snapshot == report.Snapshot {
+			report.SnapshotIndex = i
+		}
+	}
+
+	// last report of snapshot doesn't include it's tail and snapshots which
+	// are sent between reports are not reported at all, so estimated sizes
+	// are used to compute size of already sent snapshots when possible
+	snapshots := progress.Estimate.Snapshots
+	switch {
+	case report.SnapshotIndex >= 0:
+		tracker.completed = getSnapshotsSize(snapshots[:report.SnapshotIndex])
+
+	case report.SnapshotChanged && tracker.index >= 0:
+		tracker.completed = getSnapshotsSize(snapshots[:tracker.index+1])
+
+	case report.SnapshotChanged:
+		tracker.completed += tracker.size
+	}
+
+	sent := tracker.completed + report.Size
+
+	if !tracker.timestamp.IsZero() && !report.Timestamp.IsZero() {
+		tracker.rate = smoothRate(
+			tracker.rate,
+			sent-previous,
+			report.Timestamp.Sub(tracker.timestamp),
+		)
+	}
+
+	report.Sent = sent
+	report.Rate = tracker.rate
+	report.Percent = getPercent(sent, progress.SendSize)
+	report.Remaining = getRemaining(sent, progress.SendSize, tracker.rate)
+
+	tracker.size = report.Size
+	tracker.snapshot = report.Snapshot
+	tracker.index = report.SnapshotIndex
+
+	if !report.Timestamp.IsZero() {
+		tracker.timestamp = report.Timestamp
+	}
+}
+
+// getSnapshotsSize returns total estimated size of given snapshot streams.
+func getSnapshotsSize(snapshots []SendEstimateSnapshot) int64 {
+	size := int64(0)
+	for _, snapshot := range snapshots {
+		size += snapshot.Size
+	}
+
+	return size
+}
+
+// smoothRate returns rate which is smoothed with given amount of bytes which
+// are transferred in given time.
+func smoothRate(rate float64, size int64, elapsed time.Duration) float64 {
+	if elapsed <= 0 {
+		return rate
+	}
+
+	measured := float64(size) / elapsed.Seconds()
+	if rate == 0 {
+		return measured
+	}
+
+	return rateSmoothing*measured + (1-rateSmoothing)*rate
+}
+
+// getPercent returns percent of transferred bytes or zero if total size is
+// not known.
+func getPercent(size int64, total int64) float64 {
+	if total <= 0 {
+		return 0
+	}
+
+	percent := float64(size) / float64(total) * 100
+	if percent > 100 {
+		return 100
+	}
+
+	return percent
+}
+
+// getRemaining returns estimated duration which is needed to transfer
+// remaining bytes with given rate or zero if it's can't be estimated.
+func getRemaining(size int64, total int64, rate float64) time.Duration {
+	if rate <= 0 || total <= size {
+		return 0
+	}
+
+	seconds := float64(total-size) / rate
+
+	return time.Duration(seconds * float64(time.Second))
+}
+
+// parseTime parses report time token as local time of current day or of the
+// next day, if time is wrapped over midnight since previous report.
+func (tracker *sendProgressTracker) parseTime(token string) time.Time {
+	clock, err := time.Parse("15:04:05", token)
+	if err != nil {
+		return time.Time{}
+	}
+
+	day := tracker.timestamp
+	if day.IsZero() {
+		day = time.Now()
+	}
+
+	timestamp := time.Date(
+		day.Year(), day.Month(), day.Day(),
+		clock.Hour(), clock.Minute(), clock.Second(), 0,
+		time.Local,
+	)
+
+	if !tracker.timestamp.IsZero() && timestamp.Before(tracker.timestamp) {
+		timestamp = timestamp.AddDate(0, 0, 1)
+	}
+
+	return timestamp
+}
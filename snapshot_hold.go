@@ -0,0 +1,49 @@
+package zfs
+
+import (
+	"io"
+	"strconv"
+	"time"
+)
+
+// SnapshotHold represents single user hold placed on snapshot.
+type SnapshotHold struct {
+	// Snapshot is a name of held snapshot.
+	Snapshot string
+
+	// Tag is a hold tag.
+	Tag string
+
+	// Created is a time when hold was placed.
+	Created time.Time
+}
+
+// readSnapshotHolds reads `zfs holds -H -p` output.
+func readSnapshotHolds(reader io.Reader) ([]SnapshotHold, error) {
+	var (
+		parser = NewParser(reader, 3)
+		holds  = []SnapshotHold{}
+	)
+
+	for {
+		fields, err := parser.Next()
+		if err == io.EOF {
+			return holds, nil
+		}
+
+		if err != nil {
+			return nil, err
+		}
+
+		created, err := strconv.ParseInt(fields[2], 10, 64)
+		if err != nil {
+			return nil, err
+		}
+
+		holds = append(holds, SnapshotHold{
+			Snapshot: fields[0],
+			Tag:      fields[1],
+			Created:  time.Unix(created, 0),
+		})
+	}
+}
@@ -1,25 +1,69 @@
 package zfs
 
-// Source describes where property is coming from.
-type Source string
+import "strings"
+
+// SourceKind describes kind of place where property is coming from.
+type SourceKind string
 
 const (
 	// SourceLocal means that property is locally set on given FS.
-	SourceLocal Source = "local"
+	SourceLocal SourceKind = "local"
 
 	// SourceDefault means that property is not modified.
-	SourceDefault = "default"
+	SourceDefault SourceKind = "default"
 
-	// SourceInherit means that property is inherited from parent FS.
-	SourceInherit = "inherit"
+	// SourceInherit means that property is inherited from parent FS, which
+	// name is stored in Source.InheritedFrom.
+	SourceInherit SourceKind = "inherited"
 
 	// SourceReceived means that property was received via `zfs receive`.
-	SourceReceived = "received"
+	SourceReceived SourceKind = "received"
 
 	// SourceTemporary means that property is set as a result from legacy mount
 	// call.
-	SourceTemporary = "temporary"
+	SourceTemporary SourceKind = "temporary"
 
 	// SourceNone means that property is internal and has no external source.
-	SourceNone = "-"
+	SourceNone SourceKind = "-"
 )
+
+// sourceInheritedPrefix is a prefix which is used by zfs to report that
+// property is inherited, followed by parent FS name.
+const sourceInheritedPrefix = "inherited from "
+
+// Source describes where property is coming from.
+type Source struct {
+	// Kind is a kind of source.
+	Kind SourceKind
+
+	// InheritedFrom is a name of FS which property is inherited from. It's
+	// set only if Kind is SourceInherit.
+	InheritedFrom string
+}
+
+// ParseSource parses source as it's reported in `zfs get` output, e.g.
+// `local` or `inherited from zroot`.
+func ParseSource(value string) Source {
+	if strings.HasPrefix(value, sourceInheritedPrefix) {
+		return Source{
+			Kind:          SourceInherit,
+			InheritedFrom: strings.TrimPrefix(value, sourceInheritedPrefix),
+		}
+	}
+
+	return Source{Kind: SourceKind(value)}
+}
+
+// IsInherited returns true if property is inherited from parent FS.
+func (source Source) IsInherited() bool {
+	return source.Kind == SourceInherit
+}
+
+// String returns source in the same form as zfs reports it.
+func (source Source) String() string {
+	if source.IsInherited() {
+		return sourceInheritedPrefix + source.InheritedFrom
+	}
+
+	return string(source.Kind)
+}
@@ -0,0 +1,16 @@
+package zfs
+
+// TrimOptions used in conjunction with Trim() method and controls behaviour
+// of TRIM.
+type TrimOptions struct {
+	// Rate limits TRIM rate in bytes per second for every device. Zero
+	// means that TRIM is issued as fast as possible.
+	Rate Size
+
+	// Secure will use secure TRIM, so trimmed data is guaranteed to be
+	// erased. Not all devices support it.
+	Secure bool
+
+	// Wait will block until TRIM is completed.
+	Wait bool
+}
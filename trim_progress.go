@@ -0,0 +1,147 @@
+package zfs
+
+import (
+	"regexp"
+	"strconv"
+	"time"
+)
+
+// TrimState is a state of device TRIM.
+type TrimState string
+
+const (
+	// TrimUntrimmed means that device never has been trimmed.
+	TrimUntrimmed TrimState = "untrimmed"
+
+	// TrimActive means that device is being trimmed.
+	TrimActive TrimState = "active"
+
+	// TrimSuspended means that TRIM is suspended and can be resumed.
+	TrimSuspended TrimState = "suspended"
+
+	// TrimComplete means that TRIM is completed.
+	TrimComplete TrimState = "complete"
+
+	// TrimUnsupported means that device does not support TRIM.
+	TrimUnsupported TrimState = "unsupported"
+)
+
+// TrimProgress represents TRIM state of single leaf device, as reported by
+// `zpool status -t`.
+type TrimProgress struct {
+	// Device is a device name.
+	Device string
+
+	// State is a TRIM state.
+	State TrimState
+
+	// Percent is a completion percentage.
+	Percent int
+
+	// Time is a time when TRIM is started or completed, depending on State.
+	Time time.Time
+}
+
+var (
+	trimProgress = regexp.MustCompile(
+		`\((\d+)% trimmed(, suspended)?, (started|completed) at ([^)]+)\)`,
+	)
+	trimUntrimmed   = regexp.MustCompile(`\(untrimmed\)`)
+	trimUnsupported = regexp.MustCompile(`\(trim unsupported\)`)
+)
+
+// TrimProgress returns TRIM progress of all leaf devices of pool, which
+// report it. Status must be obtained with TRIM details requested.
+func (status PoolStatus) TrimProgress() ([]TrimProgress, error) {
+	result := []TrimProgress{}
+
+	groups := [][]Vdev{
+		{status.Vdevs},
+		status.Logs,
+		status.Special,
+		status.Dedup,
+	}
+
+	for _, vdevs := range groups {
+		var err error
+
+		result, err = appendTrimProgress(result, vdevs)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	return result, nil
+}
+
+func appendTrimProgress(
+	result []TrimProgress,
+	vdevs []Vdev,
+) ([]TrimProgress, error) {
+	for _, vdev := range vdevs {
+		if len(vdev.Children) > 0 {
+			var err error
+
+			result, err = appendTrimProgress(result, vdev.Children)
+			if err != nil {
+				return nil, err
+			}
+
+			continue
+		}
+
+		progress, ok, err := parseTrimProgress(vdev)
+		if err != nil {
+			return nil, err
+		}
+
+		if ok {
+			result = append(result, progress)
+		}
+	}
+
+	return result, nil
+}
+
+// parseTrimProgress parses TRIM state which is printed after vdev state in
+// parentheses.
+func parseTrimProgress(vdev Vdev) (TrimProgress, bool, error) {
+	progress := TrimProgress{Device: vdev.Name}
+
+	switch {
+	case trimUntrimmed.MatchString(vdev.Message):
+		progress.State = TrimUntrimmed
+
+	case trimUnsupported.MatchString(vdev.Message):
+		progress.State = TrimUnsupported
+
+	default:
+		matches := trimProgress.FindStringSubmatch(vdev.Message)
+		if matches == nil {
+			return TrimProgress{}, false, nil
+		}
+
+		switch {
+		case matches[2] != "":
+			progress.State = TrimSuspended
+		case matches[3] == "completed":
+			progress.State = TrimComplete
+		default:
+			progress.State = TrimActive
+		}
+
+		var err error
+
+		progress.Percent, err = strconv.Atoi(matches[1])
+		if err != nil {
+			return TrimProgress{}, false, err
+		}
+
+		progress.Time, err = parseStatusTime(matches[4])
+		if err != nil {
+			return TrimProgress{}, false, err
+		}
+	}
+
+	return progress, true, nil
+}
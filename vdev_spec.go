@@ -0,0 +1,152 @@
+package zfs
+
+import (
+	"strconv"
+)
+
+// VdevType is a type of top-level vdev in pool layout.
+type VdevType string
+
+const (
+	// VdevStripe means that devices are added as separate top-level vdevs
+	// without redundancy.
+	VdevStripe VdevType = ""
+
+	// VdevMirror is a mirror of devices.
+	VdevMirror VdevType = "mirror"
+
+	// VdevRaidz1 is a RAID-Z with single parity.
+	VdevRaidz1 VdevType = "raidz1"
+
+	// VdevRaidz2 is a RAID-Z with double parity.
+	VdevRaidz2 VdevType = "raidz2"
+
+	// VdevRaidz3 is a RAID-Z with triple parity.
+	VdevRaidz3 VdevType = "raidz3"
+
+	// VdevDraid1 is a distributed RAID with single parity.
+	VdevDraid1 VdevType = "draid1"
+
+	// VdevDraid2 is a distributed RAID with double parity.
+	VdevDraid2 VdevType = "draid2"
+
+	// VdevDraid3 is a distributed RAID with triple parity.
+	VdevDraid3 VdevType = "draid3"
+)
+
+// VdevGroup is a single group of devices of given type, e.g. one mirror.
+type VdevGroup struct {
+	// Type is a type of group.
+	Type VdevType
+
+	// Devices is a list of device names or paths.
+	Devices []string
+
+	// DraidData is an amount of data devices per redundancy group. Used
+	// only with dRAID, zero means default.
+	DraidData int
+
+	// DraidSpares is an amount of distributed spares. Used only with dRAID.
+	DraidSpares int
+}
+
+// VdevSpec describes pool layout which is used to create pool.
+type VdevSpec struct {
+	// Data is a list of groups which store pool data.
+	Data []VdevGroup
+
+	// Log is a list of separate intent log groups.
+	Log []VdevGroup
+
+	// Special is a list of special allocation class groups.
+	Special []VdevGroup
+
+	// Cache is a list of cache devices.
+	Cache []string
+
+	// Spares is a list of hot spares.
+	Spares []string
+}
+
+// args returns `zpool create` args which describe layout.
+func (spec VdevSpec) args() ([]string, error) {
+	if len(spec.Data) == 0 {
+		return nil, ErrInvalidVdevSpec{Reason: "no data vdevs specified"}
+	}
+
+	args := []string{}
+
+	groups := []struct {
+		keyword string
+		groups  []VdevGroup
+	}{
+		{"", spec.Data},
+		{"log", spec.Log},
+		{"special", spec.Special},
+	}
+
+	for _, class := range groups {
+		if len(class.groups) == 0 {
+			continue
+		}
+
+		if class.keyword != "" {
+			args = append(args, class.keyword)
+		}
+
+		for _, group := range class.groups {
+			groupArgs, err := group.args()
+			if err != nil {
+				return nil, err
+			}
+
+			args = append(args, groupArgs...)
+		}
+	}
+
+	if len(spec.Cache) > 0 {
+		args = append(append(args, "cache"), spec.Cache...)
+	}
+
+	if len(spec.Spares) > 0 {
+		args = append(append(args, "spare"), spec.Spares...)
+	}
+
+	return args, nil
+}
+
+func (group VdevGroup) args() ([]string, error) {
+	if len(group.Devices) == 0 {
+		return nil, ErrInvalidVdevSpec{
+			Type:   group.Type,
+			Reason: "no devices specified",
+		}
+	}
+
+	isDraid := group.Type == VdevDraid1 ||
+		group.Type == VdevDraid2 ||
+		group.Type == VdevDraid3
+
+	if !isDraid && (group.DraidData > 0 || group.DraidSpares > 0) {
+		return nil, ErrInvalidVdevSpec{
+			Type:   group.Type,
+			Reason: "dRAID options are specified for non-dRAID vdev",
+		}
+	}
+
+	if group.Type == VdevStripe {
+		return group.Devices, nil
+	}
+
+	kind := string(group.Type)
+
+	if group.DraidData > 0 {
+		kind += ":" + strconv.Itoa(group.DraidData) + "d"
+	}
+
+	if group.DraidSpares > 0 {
+		kind += ":" + strconv.Itoa(group.DraidSpares) + "s"
+	}
+
+	return append([]string{kind}, group.Devices...), nil
+}
@@ -1,9 +1,15 @@
 package zfs
 
 import (
+	"bufio"
 	"bytes"
+	"context"
+	"errors"
 	"fmt"
 	"io"
+	"io/ioutil"
+	"strconv"
+	"strings"
 
 	"github.com/kovetskiy/runcmd"
 	"github.com/reconquest/lexec-go"
@@ -13,36 +19,56 @@ import (
 // ZFS is a handle to access various zfs operations, it's not linked to single
 // pool and can work with any FS (even on remote FS, if remote runner
 // is provided).
+//
+// ZFS is safe for concurrent use by multiple goroutines as long as it's not
+// modified by SetRunner() or SetLogger() meanwhile. Methods like WithSudo()
+// or WithContext() return modified copy and leave original handle intact.
 type ZFS struct {
 	*Runner
 }
 
 // NewZFS returns new zfs handle linked to local FS.
 func NewZFS() (*ZFS, error) {
-	runner, err := runcmd.NewLocalRunner()
-
-	return &ZFS{&Runner{Binary: "zfs", Runner: runner}}, err
+	return &ZFS{&Runner{Binary: "zfs", Runner: NewLocalRunner()}}, nil
 }
 
 // Sudo changes state of zfs handle so next execution will be done with
 // privileged rights.
+//
+// Deprecated: Sudo modifies handle, so it's not safe for concurrent use. Use
+// WithSudo() instead, which returns privileged copy of handle.
 func (zfs *ZFS) Sudo() *ZFS {
 	zfs.Runner.Sudo = true
 
 	return zfs
 }
 
-// SetRunner sets runner which will be used to execute zfs binary.
+// WithSudo is a same as Runner.WithSudo(), but returns zfs handle.
+func (zfs *ZFS) WithSudo() *ZFS {
+	return &ZFS{zfs.Runner.WithSudo()}
+}
+
+// WithEscalator is a same as Runner.WithEscalator(), but returns zfs
+// handle.
+func (zfs *ZFS) WithEscalator(escalator Escalator) *ZFS {
+	return &ZFS{zfs.Runner.WithEscalator(escalator)}
+}
+
+// WithContext is a same as Runner.WithContext(), but returns zfs handle.
+func (zfs *ZFS) WithContext(ctx context.Context) *ZFS {
+	return &ZFS{zfs.Runner.WithContext(ctx)}
+}
+
+// SetRunner is a same as Runner.SetRunner(), but returns zfs handle.
 func (zfs *ZFS) SetRunner(runner runcmd.Runner) *ZFS {
-	zfs.Runner.Runner = runner
+	zfs.Runner.SetRunner(runner)
 
 	return zfs
 }
 
-// SetLogger sets logger which will be used to log zfs binary starts, exit
-// codes as well as stdout/stderr.
+// SetLogger is a same as Runner.SetLogger(), but returns zfs handle.
 func (zfs *ZFS) SetLogger(logger lexec.Logger) *ZFS {
-	zfs.Runner.Logger = logger
+	zfs.Runner.SetLogger(logger)
 
 	return zfs
 }
@@ -50,67 +76,313 @@ func (zfs *ZFS) SetLogger(logger lexec.Logger) *ZFS {
 // List lists all filesystems that are starting with specified prefix.
 // Prefix '/' can be used to list all FS.
 func (zfs *ZFS) List(prefix string) ([]FS, error) {
-	command := zfs.Command("get", "all", "-H", "-p", "-r", prefix)
+	return zfs.ListWithOptions(prefix, ListOptions{Recursive: true})
+}
 
-	stdout, _, err := command.Output()
+// ListWithOptions is a same as List(), but options can be specified to filter
+// listed FS by type and depth, to retrieve only specified properties and to
+// sort result.
+func (zfs *ZFS) ListWithOptions(
+	prefix string,
+	options ListOptions,
+) ([]FS, error) {
+	err := options.Validate()
 	if err != nil {
 		return nil, err
 	}
 
-	reader := bytes.NewReader(stdout)
+	result, err := zfs.get(getListArgs(prefix, options)...)
+	if err != nil {
+		return nil, err
+	}
 
-	var (
-		name     string
-		property string
-		value    string
-		source   string
-	)
+	sortFS(result, options.Sort)
 
-	result := []FS{}
+	return result, nil
+}
 
-	fs := &FS{}
+// getListArgs returns `zfs get` args which corresponds to given list
+// options.
+func getListArgs(prefix string, options ListOptions) []string {
+	args := []string{}
 
-	for {
-		_, err := fmt.Fscanf(
-			reader,
-			"%s %s %s %s\n",
-			&name,
-			&property,
-			&value,
-			&source,
-		)
+	if options.Depth > 0 {
+		args = append(args, "-d", strconv.Itoa(options.Depth))
+	} else if options.Recursive {
+		args = append(args, "-r")
+	}
 
-		if name != fs.Name || err == io.EOF {
-			if fs.Name != "" {
-				result = append(result, *fs)
-			}
+	if len(options.Types) > 0 {
+		types := []string{}
+		for _, kind := range options.Types {
+			types = append(types, string(kind))
+		}
+
+		args = append(args, "-t", strings.Join(types, ","))
+	}
 
-			fs = &FS{
-				Name:       name,
-				Properties: map[string]Property{},
+	properties := "all"
+	if len(options.Properties) > 0 {
+		names := append([]string{}, options.Properties...)
+		for _, key := range options.Sort {
+			if !contains(names, key.Property) {
+				names = append(names, key.Property)
 			}
 		}
 
-		if err != nil {
-			if err == io.EOF {
-				break
-			}
+		properties = strings.Join(names, ",")
+	}
+
+	return append(args, properties, prefix)
+}
+
+func contains(list []string, item string) bool {
+	for _, value := range list {
+		if value == item {
+			return true
+		}
+	}
+
+	return false
+}
+
+// propertiesColumns is a list of columns which is requested from `zfs get`
+// and expected by readFS().
+const propertiesColumns = "name,property,value,received,source"
+
+// get runs `zfs get` with specified args and returns FS list populated with
+// retrieved properties. Args should end with property list and target.
+func (zfs *ZFS) get(args ...string) ([]FS, error) {
+	command := zfs.getCommand(args...)
+
+	stdout, _, err := command.Output()
+	if err != nil {
+		return nil, err
+	}
+
+	result, err := readFS(bytes.NewReader(stdout))
+	if err != nil {
+		return nil, ser.Errorf(
+			err,
+			"error while reading command output: '%s'",
+			command.String(),
+		)
+	}
+
+	return result, nil
+}
+
+func (zfs *ZFS) getCommand(args ...string) Command {
+	return zfs.Command(
+		append(
+			[]string{"get", "-H", "-p", "-o", propertiesColumns},
+			args...,
+		)...,
+	)
+}
+
+// Create creates new FS (or volume, if VolumeSize is specified) with given
+// name and returns it populated with all properties, like List() does.
+func (zfs *ZFS) Create(name string, options CreateOptions) (FS, error) {
+	err := options.Validate()
+	if err != nil {
+		return FS{}, err
+	}
+
+	args := []string{"create"}
+
+	if options.CreateParents {
+		args = append(args, "-p")
+	}
+
+	if options.NotMount && options.VolumeSize == 0 {
+		args = append(args, "-u")
+	}
+
+	for _, pair := range options.Properties.Pairs() {
+		args = append(args, "-o", pair)
+	}
+
+	if options.Encryption != "" {
+		args = append(args, "-o", "encryption="+options.Encryption)
+	}
+
+	if options.KeyFormat != "" {
+		args = append(args, "-o", "keyformat="+options.KeyFormat)
+	}
 
-			return nil, ser.Errorf(
-				err,
-				"error while reading command output: '%s'",
-				command.String(),
+	if options.KeyLocation != "" {
+		args = append(args, "-o", "keylocation="+options.KeyLocation)
+	}
+
+	if options.VolumeSize > 0 {
+		if options.Sparse {
+			args = append(args, "-s")
+		}
+
+		if options.BlockSize > 0 {
+			args = append(
+				args,
+				"-b", strconv.FormatInt(options.BlockSize.AsInt64(), 10),
 			)
 		}
 
-		fs.Properties[property] = Property{
-			Name:   property,
-			Value:  value,
-			Source: Source(source),
+		args = append(
+			args,
+			"-V", strconv.FormatInt(options.VolumeSize.AsInt64(), 10),
+		)
+	}
+
+	args = append(args, name)
+
+	err = zfs.keyCommand(options.Key, args...).Execute()
+	if err != nil {
+		return FS{}, err
+	}
+
+	filesystems, err := zfs.get("all", name)
+	if err != nil {
+		return FS{}, ser.Errorf(
+			err,
+			"can't get properties of created FS: %s",
+			name,
+		)
+	}
+
+	for _, fs := range filesystems {
+		if fs.Name == name {
+			return fs, nil
 		}
 	}
 
-	return result, nil
+	return FS{}, fmt.Errorf("created FS is not found: %s", name)
+}
+
+// GetProperties returns specified FS with only given properties retrieved.
+// If no property names are given, all properties will be retrieved.
+func (zfs *ZFS) GetProperties(target string, names ...string) (FS, error) {
+	list := "all"
+	if len(names) > 0 {
+		list = strings.Join(names, ",")
+	}
+
+	filesystems, err := zfs.get(list, target)
+	if err != nil {
+		return FS{}, err
+	}
+
+	for _, fs := range filesystems {
+		if fs.Name == target {
+			return fs, nil
+		}
+	}
+
+	return FS{Name: target, Properties: map[string]Property{}}, nil
+}
+
+// SetProperties sets given properties on specified FS.
+func (zfs *ZFS) SetProperties(target string, properties Properties) error {
+	if len(properties) == 0 {
+		return nil
+	}
+
+	args := append([]string{"set"}, properties.Pairs()...)
+	args = append(args, target)
+
+	return zfs.Command(args...).Execute()
+}
+
+// InheritProperty clears specified property on given FS, so it will be
+// inherited from parent FS. If recursive is true, property will be inherited
+// on all descendents as well. If received is true, property will be reverted
+// to the received value instead of being inherited.
+func (zfs *ZFS) InheritProperty(
+	target string,
+	name string,
+	recursive bool,
+	received bool,
+) error {
+	args := []string{"inherit"}
+
+	if recursive {
+		args = append(args, "-r")
+	}
+
+	if received {
+		args = append(args, "-S")
+	}
+
+	args = append(args, name, target)
+
+	return zfs.Command(args...).Execute()
+}
+
+// LoadKey loads encryption key of specified FS, so it can be mounted.
+func (zfs *ZFS) LoadKey(target string, source KeySource) error {
+	args := []string{"load-key"}
+
+	if source.Recursive {
+		args = append(args, "-r")
+	}
+
+	location := source.Location
+	if source.Key != nil && location == "" {
+		location = "prompt"
+	}
+
+	if location != "" {
+		args = append(args, "-L", location)
+	}
+
+	args = append(args, target)
+
+	return zfs.keyCommand(source.Key, args...).Execute()
+}
+
+// keyCommand returns command which reads given key material (if any) from
+// stdin. Output of such command is never logged, because zfs may echo key
+// material.
+func (zfs *ZFS) keyCommand(key []byte, args ...string) Command {
+	command := zfs.Command(args...)
+
+	if key != nil {
+		command.NoLog()
+		command.SetStdin(bytes.NewReader(key))
+	}
+
+	return command
+}
+
+// UnloadKey unloads encryption key of specified FS, which should be
+// unmounted.
+func (zfs *ZFS) UnloadKey(target string) error {
+	return zfs.Command("unload-key", target).Execute()
+}
+
+// ChangeKey changes encryption key of specified FS or makes it inheriting
+// key of parent FS.
+func (zfs *ZFS) ChangeKey(target string, options ChangeKeyOptions) error {
+	args := []string{"change-key"}
+
+	if options.Load {
+		args = append(args, "-l")
+	}
+
+	if options.Inherit {
+		args = append(args, "-i")
+	}
+
+	if options.KeyFormat != "" {
+		args = append(args, "-o", "keyformat="+options.KeyFormat)
+	}
+
+	if options.KeyLocation != "" {
+		args = append(args, "-o", "keylocation="+options.KeyLocation)
+	}
+
+	args = append(args, target)
+
+	return zfs.keyCommand(options.Key, args...).Execute()
 }
 
 // Snapshot snapshots specified FS.
@@ -118,15 +390,173 @@ func (zfs *ZFS) Snapshot(target string, name string) error {
 	return zfs.Command("snapshot", target+"@"+name).Execute()
 }
 
-// Destroy destroys specified FS.
+// Bookmark creates bookmark with specified name from given snapshot (or
+// another bookmark). Bookmark is created for the same FS as snapshot.
+func (zfs *ZFS) Bookmark(snapshot string, name string) error {
+	return zfs.Command(
+		"bookmark",
+		snapshot,
+		getDatasetName(snapshot)+"#"+name,
+	).Execute()
+}
+
+// Destroy destroys specified FS. ErrSnapshotHeld will be returned if
+// specified FS is a snapshot which has user holds.
 func (zfs *ZFS) Destroy(target string) error {
-	return zfs.Command("destroy", target).Execute()
+	return zfs.destroy(target, "destroy", target)
 }
 
 // DestroyRecursive destroys specified FS and all it's descendents. Scope
 // of destroy can be controlled with corresponding parameter.
 func (zfs *ZFS) DestroyRecursive(target string, scope DestroyScope) error {
-	return zfs.Command("destroy", string(scope), target).Execute()
+	return zfs.destroy(target, "destroy", string(scope), target)
+}
+
+// destroy runs `zfs destroy` with given args and checks if busy snapshot
+// can't be destroyed because of holds.
+func (zfs *ZFS) destroy(target string, args ...string) error {
+	err := zfs.Command(args...).Execute()
+	if err == nil {
+		return nil
+	}
+
+	if !strings.Contains(target, "@") || !errors.Is(err, ErrDatasetBusy) {
+		return err
+	}
+
+	holds, holdsErr := zfs.Holds(target)
+	if holdsErr != nil || len(holds) == 0 {
+		return err
+	}
+
+	return ErrSnapshotHeld{
+		Snapshot: target,
+		Holds:    holds,
+		Err:      err,
+	}
+}
+
+// Hold places hold with specified tag on given snapshots, so they can't be
+// destroyed until hold is released.
+func (zfs *ZFS) Hold(tag string, snapshots ...string) error {
+	return zfs.Command(
+		append([]string{"hold", tag}, snapshots...)...,
+	).Execute()
+}
+
+// HoldRecursive is a same as Hold(), but hold is placed on snapshots with
+// the same name of all descendent FS as well.
+func (zfs *ZFS) HoldRecursive(tag string, snapshots ...string) error {
+	return zfs.Command(
+		append([]string{"hold", "-r", tag}, snapshots...)...,
+	).Execute()
+}
+
+// Release releases hold with specified tag from given snapshots.
+func (zfs *ZFS) Release(tag string, snapshots ...string) error {
+	return zfs.Command(
+		append([]string{"release", tag}, snapshots...)...,
+	).Execute()
+}
+
+// ReleaseRecursive is a same as Release(), but hold is released from
+// snapshots with the same name of all descendent FS as well.
+func (zfs *ZFS) ReleaseRecursive(tag string, snapshots ...string) error {
+	return zfs.Command(
+		append([]string{"release", "-r", tag}, snapshots...)...,
+	).Execute()
+}
+
+// Holds returns list of holds placed on specified snapshot.
+func (zfs *ZFS) Holds(snapshot string) ([]SnapshotHold, error) {
+	command := zfs.Command("holds", "-H", "-p", snapshot)
+
+	stdout, _, err := command.Output()
+	if err != nil {
+		return nil, err
+	}
+
+	holds, err := readSnapshotHolds(bytes.NewReader(stdout))
+	if err != nil {
+		return nil, ser.Errorf(
+			err,
+			"error while reading command output: '%s'",
+			command.String(),
+		)
+	}
+
+	return holds, nil
+}
+
+// Diff returns changes of files between snapshot `from` and snapshot or FS
+// `to`. If `to` is empty, snapshot is compared with current state of it's FS.
+// Use DiffIter() for large diffs to avoid buffering all changes in memory.
+func (zfs *ZFS) Diff(
+	from string,
+	to string,
+	options DiffOptions,
+) ([]DiffChange, error) {
+	iterator, err := zfs.DiffIter(from, to, options)
+	if err != nil {
+		return nil, err
+	}
+
+	defer iterator.Close()
+
+	changes := []DiffChange{}
+	for iterator.Next() {
+		changes = append(changes, iterator.Change())
+	}
+
+	return changes, iterator.Err()
+}
+
+// Rename renames specified FS into another name.
+func (zfs *ZFS) Rename(
+	source string,
+	target string,
+	options RenameOptions,
+) error {
+	args := []string{"rename"}
+
+	if options.CreateParents {
+		args = append(args, "-p")
+	}
+
+	if options.Force {
+		args = append(args, "-f")
+	}
+
+	if options.NotMount {
+		args = append(args, "-u")
+	}
+
+	if options.Recursive {
+		args = append(args, "-r")
+	}
+
+	args = append(args, source, target)
+
+	return zfs.Command(args...).Execute()
+}
+
+// Promote promotes specified clone FS so it's no longer dependent on origin
+// snapshot.
+func (zfs *ZFS) Promote(target string) error {
+	return zfs.Command("promote", target).Execute()
+}
+
+// Rollback rolls back FS to specified snapshot. Snapshot should be the most
+// recent one.
+func (zfs *ZFS) Rollback(snapshot string) error {
+	return zfs.Command("rollback", snapshot).Execute()
+}
+
+// RollbackRecursive is a same as Rollback(), but will destroy any snapshots
+// more recent than specified one. Scope controls if clones of these snapshots
+// will be destroyed as well.
+func (zfs *ZFS) RollbackRecursive(snapshot string, scope DestroyScope) error {
+	return zfs.Command("rollback", string(scope), snapshot).Execute()
 }
 
 // Clone clones specified FS under another name.
@@ -152,6 +582,29 @@ func (zfs *ZFS) CloneWithProperties(
 	return zfs.Command(args...).Execute()
 }
 
+// GetResumeToken returns token which can be used to resume interrupted
+// resumable receive into given FS via SendResume(). Empty string is returned
+// if there is nothing to resume.
+func (zfs *ZFS) GetResumeToken(target string) (string, error) {
+	fs, err := zfs.GetProperties(target, "receive_resume_token")
+	if err != nil {
+		return "", err
+	}
+
+	token := fs.GetProperty("receive_resume_token")
+	if token.Value == "-" {
+		return "", nil
+	}
+
+	return token.Value, nil
+}
+
+// AbortPartialReceive discards partially received state of interrupted
+// resumable receive into given FS.
+func (zfs *ZFS) AbortPartialReceive(target string) error {
+	return zfs.Command("receive", "-A", target).Execute()
+}
+
 // Receive receives FS into specified name with optional options that controls
 // receive process.
 func (zfs *ZFS) Receive(
@@ -159,7 +612,52 @@ func (zfs *ZFS) Receive(
 	reader io.Reader,
 	options ReceiveOptions,
 ) error {
-	args := []string{"receive"}
+	args := append([]string{"receive"}, getReceiveArgs(options)...)
+	args = append(args, target)
+
+	command := zfs.Command(args...)
+	command.NoLog()
+	command.SetStdin(reader)
+
+	return command.Execute()
+}
+
+// ReceiveVerbose is a same as Receive(), but returns information about
+// received streams, which is reported by `zfs receive -v`. It can be used
+// with DryRun option to check what would be received.
+func (zfs *ZFS) ReceiveVerbose(
+	target string,
+	reader io.Reader,
+	options ReceiveOptions,
+) ([]ReceivedStream, error) {
+	args := append([]string{"receive", "-v"}, getReceiveArgs(options)...)
+	args = append(args, target)
+
+	command := zfs.Command(args...)
+	command.NoLog()
+	command.SetStdin(reader)
+
+	stdout, _, err := command.Output()
+	if err != nil {
+		return nil, err
+	}
+
+	streams, err := parseReceivedStreams(string(stdout))
+	if err != nil {
+		return nil, ser.Errorf(
+			err,
+			"error while reading command output: '%s'",
+			command.String(),
+		)
+	}
+
+	return streams, nil
+}
+
+// getReceiveArgs returns `zfs receive` args which correspond to given
+// options.
+func getReceiveArgs(options ReceiveOptions) []string {
+	args := []string{}
 
 	if options.ForceRollback {
 		args = append(args, "-F")
@@ -185,13 +683,27 @@ func (zfs *ZFS) Receive(
 		args = append(args, "-o", "origin="+options.Origin)
 	}
 
-	args = append(args, target)
+	for _, pair := range options.Properties.Pairs() {
+		args = append(args, "-o", pair)
+	}
 
-	command := zfs.Command(args...)
-	command.NoLog()
-	command.SetStdin(reader)
+	for _, name := range options.ExcludeProperties {
+		args = append(args, "-x", name)
+	}
 
-	return command.Execute()
+	if options.DryRun {
+		args = append(args, "-n")
+	}
+
+	if options.SkipHolds {
+		args = append(args, "-h")
+	}
+
+	if options.ForceUnmount {
+		args = append(args, "-M")
+	}
+
+	return args
 }
 
 // Send sends specified FS as binary stream which is written in writer with
@@ -214,7 +726,19 @@ func (zfs *ZFS) SendWithProgress(
 	options SendOptions,
 	callback func(SendProgress),
 ) error {
-	args := []string{"send"}
+	err := options.validate(source)
+	if err != nil {
+		return err
+	}
+
+	args := append([]string{"send"}, getSendArgs(options)...)
+
+	return zfs.send(args, []string{source}, writer, callback)
+}
+
+// getSendArgs returns `zfs send` args which correspond to given options.
+func getSendArgs(options SendOptions) []string {
+	args := []string{}
 
 	if options.Incremental != "" {
 		if options.IncludeIntermediary {
@@ -244,17 +768,115 @@ func (zfs *ZFS) SendWithProgress(
 		args = append(args, "-p")
 	}
 
+	if options.Raw {
+		args = append(args, "-w")
+	}
+
+	if options.Holds {
+		args = append(args, "-h")
+	}
+
+	if options.BackupProperties {
+		args = append(args, "-b")
+	}
+
+	if options.Deduplicated {
+		args = append(args, "-D")
+	}
+
+	if options.Saved {
+		args = append(args, "--saved")
+	}
+
+	if options.ProcessTitle {
+		args = append(args, "-V")
+	}
+
+	if options.Redact != "" {
+		args = append(args, "--redact", options.Redact)
+	}
+
+	return args
+}
+
+// EstimateSend returns estimation of stream which will be produced by Send()
+// with the same arguments, using dry run of `zfs send`.
+func (zfs *ZFS) EstimateSend(
+	source string,
+	options SendOptions,
+) (SendEstimate, error) {
+	err := options.validate(source)
+	if err != nil {
+		return SendEstimate{}, err
+	}
+
+	args := append([]string{"send"}, getSendArgs(options)...)
+	args = append(args, "-n", "-v", "-P", source)
+
+	command := zfs.Command(args...)
+
+	stdout, stderr, err := command.output()
+	if err != nil {
+		return SendEstimate{}, command.check(stderr, err)
+	}
+
+	// depending on zfs version, dry run output is written either to stdout
+	// or to stderr
+	if len(stdout) == 0 {
+		stdout = stderr
+	}
+
+	estimate, err := readSendEstimate(
+		bufio.NewReader(bytes.NewReader(stdout)),
+	)
+	if err != nil {
+		return SendEstimate{}, ser.Errorf(
+			err,
+			"error while reading command output: '%s'",
+			command.String(),
+		)
+	}
+
+	return estimate, nil
+}
+
+// SendResume resumes send which was interrupted, using token which can be
+// obtained from receiving side via GetResumeToken(). Callback can be nil,
+// otherwise it will be called the same way as in SendWithProgress().
+func (zfs *ZFS) SendResume(
+	token string,
+	writer io.Writer,
+	callback func(SendProgress),
+) error {
+	return zfs.send(
+		[]string{"send"},
+		[]string{"-t", token},
+		writer,
+		callback,
+	)
+}
+
+// send runs `zfs send` with specified args followed by target args and
+// reports progress, if callback is given.
+func (zfs *ZFS) send(
+	args []string,
+	target []string,
+	writer io.Writer,
+	callback func(SendProgress),
+) error {
 	if callback != nil {
 		args = append(args, "-v", "-P")
 	}
 
-	args = append(args, source)
+	args = append(args, target...)
+
+	source := target[len(target)-1]
 
 	command := zfs.Command(args...)
 	command.NoLog()
 	command.SetStdout(writer)
 
-	stderr, err := command.StderrPipe()
+	pipe, err := command.StderrPipe()
 	if err != nil {
 		return ser.Errorf(
 			err,
@@ -263,8 +885,16 @@ func (zfs *ZFS) SendWithProgress(
 		)
 	}
 
+	// stderr is kept to be reported in case if zfs send fails
+	output := &bytes.Buffer{}
+	stderr := bufio.NewReader(io.TeeReader(pipe, output))
+
 	err = command.Start()
 	if err != nil {
+		if _, ok := err.(ErrCanceled); ok {
+			return err
+		}
+
 		return ser.Errorf(
 			err,
 			"can't start zfs send: %s",
@@ -273,20 +903,29 @@ func (zfs *ZFS) SendWithProgress(
 	}
 
 	if callback != nil {
-		var progress SendProgress
-
-		_, progress.Error = fmt.Fscanf(
-			stderr,
-			"%s %s %d\nsize %d\n",
-			&progress.Type,
-			&progress.Source,
-			&progress.SourceSize,
-			&progress.SendSize,
+		var (
+			progress SendProgress
+			tracker  sendProgressTracker
 		)
 
+		estimate, err := readSendEstimate(stderr)
+		if err != nil {
+			progress.Error = err
+		} else {
+			progress.Estimate = estimate
+			progress.Type = estimate.Type
+			progress.Source = estimate.Snapshots[0].Snapshot
+			progress.SourceSize = estimate.Snapshots[0].Size
+			progress.SendSize = estimate.Size
+		}
+
 		callback(progress)
 
 		for {
+			if command.canceled() != nil {
+				break
+			}
+
 			progress.Reported = true
 
 			_, progress.Error = fmt.Fscanf(
@@ -297,10 +936,14 @@ func (zfs *ZFS) SendWithProgress(
 				&progress.Report.Snapshot,
 			)
 
-			if progress.Error == io.EOF {
+			if progress.Error == io.EOF || command.canceled() != nil {
 				break
 			}
 
+			if progress.Error == nil {
+				tracker.track(&progress)
+			}
+
 			callback(progress)
 
 			if progress.Error != nil {
@@ -309,5 +952,19 @@ func (zfs *ZFS) SendWithProgress(
 		}
 	}
 
-	return command.Wait()
+	_, err = io.Copy(ioutil.Discard, stderr)
+	if err != nil {
+		return ser.Errorf(
+			err,
+			"can't read stderr of zfs send: %s",
+			source,
+		)
+	}
+
+	err = command.Wait()
+	if err != nil || callback == nil {
+		return command.check(output.Bytes(), err)
+	}
+
+	return nil
 }
@@ -1,11 +1,18 @@
 package zfs
 
 import (
+	"bytes"
+	"context"
+	"errors"
+	"io"
 	"io/ioutil"
 	"strings"
+	"sync"
 	"testing"
+	"time"
 
 	"github.com/kovetskiy/runcmd"
+	"github.com/reconquest/lexec-go"
 	"github.com/reconquest/nopio-go"
 	"github.com/stretchr/testify/assert"
 )
@@ -18,8 +25,8 @@ func TestZFS_List_ReturnsFileSystemsList(t *testing.T) {
 
 	zfs.SetRunner(&runcmd.MockRunner{
 		Stdout: asBytes(
-			"zroot/a type filesystem -",
-			"zroot/b type filesystem -",
+			"zroot/a\ttype\tfilesystem\t-\t-",
+			"zroot/b\ttype\tfilesystem\t-\t-",
 		),
 	})
 
@@ -39,9 +46,9 @@ func TestZFS_List_ReturnsFileSystemsWithSize(t *testing.T) {
 
 	zfs.SetRunner(&runcmd.MockRunner{
 		Stdout: asBytes(
-			"zroot/a used 3072 -",
-			"zroot/a available 4096 -",
-			"zroot/a referenced 2048 -",
+			"zroot/a\tused\t3072\t-\t-",
+			"zroot/a\tavailable\t4096\t-\t-",
+			"zroot/a\treferenced\t2048\t-\t-",
 		),
 	})
 
@@ -65,6 +72,289 @@ func TestZFS_List_ReturnsFileSystemsWithSize(t *testing.T) {
 	test.EqualValues("2.0KiB", size.String())
 }
 
+func TestZFS_List_ProperlyCallsBinary(t *testing.T) {
+	test := assert.New(t)
+
+	zfs, err := NewZFS()
+	test.NoError(err)
+
+	expectCommand(
+		test, zfs,
+		"zfs", "get", "-H", "-p",
+		"-o", "name,property,value,received,source",
+		"-r", "all", "zroot",
+	)
+
+	_, err = zfs.List("zroot")
+	test.NoError(err)
+}
+
+func TestZFS_ListWithOptions_ProperlyCallsBinary(t *testing.T) {
+	test := assert.New(t)
+
+	zfs, err := NewZFS()
+	test.NoError(err)
+
+	expectCommand(
+		test, zfs,
+		"zfs", "get", "-H", "-p",
+		"-o", "name,property,value,received,source",
+		"-d", "1", "-t", "filesystem,volume", "used,type,creation", "zroot",
+	)
+
+	_, err = zfs.ListWithOptions("zroot", ListOptions{
+		Depth:      1,
+		Types:      []Type{TypeFileSystem, TypeVolume},
+		Properties: []string{"used", "type"},
+		Sort:       []ListSort{{Property: "creation"}},
+	})
+	test.NoError(err)
+
+	expectCommand(
+		test, zfs,
+		"zfs", "get", "-H", "-p",
+		"-o", "name,property,value,received,source",
+		"all", "zroot",
+	)
+
+	_, err = zfs.ListWithOptions("zroot", ListOptions{})
+	test.NoError(err)
+}
+
+func TestZFS_ListWithOptions_RejectsClones(t *testing.T) {
+	test := assert.New(t)
+
+	zfs, err := NewZFS()
+	test.NoError(err)
+
+	zfs.SetRunner(&runcmd.MockRunner{})
+
+	options := ListOptions{Types: []Type{TypeFileSystem, TypeClone}}
+
+	_, err = zfs.ListWithOptions("zroot", options)
+	test.Equal(ErrInvalidListType{Type: TypeClone}, err)
+
+	_, err = zfs.ListIter("zroot", options)
+	test.Equal(ErrInvalidListType{Type: TypeClone}, err)
+}
+
+func TestZFS_ListIter_RejectsSort(t *testing.T) {
+	test := assert.New(t)
+
+	zfs, err := NewZFS()
+	test.NoError(err)
+
+	zfs.SetRunner(&runcmd.MockRunner{})
+
+	_, err = zfs.ListIter("zroot", ListOptions{
+		Sort: []ListSort{{Property: "used"}},
+	})
+	test.Equal(ErrUnsupportedListOption{Option: "Sort"}, err)
+}
+
+func TestZFS_ListWithOptions_SortsResult(t *testing.T) {
+	test := assert.New(t)
+
+	zfs, err := NewZFS()
+	test.NoError(err)
+
+	zfs.SetRunner(&runcmd.MockRunner{
+		Stdout: asBytes(
+			"zroot/a\tused\t300\t-\t-",
+			"zroot/a\tx:tier\tgold\t-\tlocal",
+			"zroot/b\tused\t1000\t-\t-",
+			"zroot/b\tx:tier\tgold\t-\tlocal",
+			"zroot/c\tused\t20\t-\t-",
+			"zroot/c\tx:tier\tbronze\t-\tlocal",
+		),
+	})
+
+	fs, err := zfs.ListWithOptions("zroot", ListOptions{
+		Recursive: true,
+		Sort: []ListSort{
+			{Property: "x:tier", Descending: true},
+			{Property: "used"},
+		},
+	})
+	test.NoError(err)
+	test.Len(fs, 3)
+
+	test.Equal("zroot/a", fs[0].Name)
+	test.Equal("zroot/b", fs[1].Name)
+	test.Equal("zroot/c", fs[2].Name)
+
+	fs, err = zfs.ListWithOptions("zroot", ListOptions{
+		Sort: []ListSort{{Property: "used", Descending: true}},
+	})
+	test.NoError(err)
+
+	test.Equal("zroot/b", fs[0].Name)
+	test.Equal("zroot/a", fs[1].Name)
+	test.Equal("zroot/c", fs[2].Name)
+}
+
+func TestZFS_ListIter_YieldsFileSystemsOneByOne(t *testing.T) {
+	test := assert.New(t)
+
+	zfs, err := NewZFS()
+	test.NoError(err)
+
+	zfs.SetRunner(&runcmd.MockRunner{
+		Stdout: asBytes(
+			"zroot/a\ttype\tfilesystem\t-\t-",
+			"zroot/a\tused\t3072\t-\t-",
+			"zroot/b\ttype\tfilesystem\t-\t-",
+			"zroot/b@x\ttype\tsnapshot\t-\t-",
+		),
+	})
+
+	iterator, err := zfs.ListIter("zroot", ListOptions{Recursive: true})
+	test.NoError(err)
+
+	names := []string{}
+	for iterator.Next() {
+		fs := iterator.FS()
+		names = append(names, fs.Name)
+
+		if fs.Name == "zroot/a" {
+			test.Len(fs.Properties, 2)
+		}
+	}
+
+	test.NoError(iterator.Err())
+	test.Equal([]string{"zroot/a", "zroot/b", "zroot/b@x"}, names)
+	test.NoError(iterator.Close())
+}
+
+func TestZFS_ListIter_CanBeStoppedEarly(t *testing.T) {
+	test := assert.New(t)
+
+	zfs, err := NewZFS()
+	test.NoError(err)
+
+	runner := &blockingRunner{
+		MockRunner: runcmd.MockRunner{
+			Stdout: asBytes(
+				"zroot/a\ttype\tfilesystem\t-\t-",
+				"zroot/b\ttype\tfilesystem\t-\t-",
+				"zroot/c\ttype\tfilesystem\t-\t-",
+			),
+		},
+	}
+	zfs.SetRunner(runner)
+
+	iterator, err := zfs.ListIter("zroot", ListOptions{Recursive: true})
+	test.NoError(err)
+
+	test.True(iterator.Next())
+	test.Equal("zroot/a", iterator.FS().Name)
+
+	test.NoError(iterator.Close())
+	test.True(runner.worker.isKilled())
+	test.False(iterator.Next())
+	test.NoError(iterator.Err())
+}
+
+func TestZFS_ListIter_ReturnsErrorOnMalformedOutput(t *testing.T) {
+	test := assert.New(t)
+
+	zfs, err := NewZFS()
+	test.NoError(err)
+
+	zfs.SetRunner(&runcmd.MockRunner{
+		Stdout: asBytes(
+			"zroot/a\ttype\tfilesystem\t-\t-",
+			"zroot/b type filesystem",
+		),
+	})
+
+	iterator, err := zfs.ListIter("zroot", ListOptions{})
+	test.NoError(err)
+
+	test.False(iterator.Next())
+	test.Error(iterator.Err())
+}
+
+func TestZFS_List_PreservesValuesWithSpaces(t *testing.T) {
+	test := assert.New(t)
+
+	zfs, err := NewZFS()
+	test.NoError(err)
+
+	zfs.SetRunner(&runcmd.MockRunner{
+		Stdout: asBytes(
+			"zroot/a b\tmountpoint\t/mnt/my data\t-\tlocal",
+			"zroot/a b\tx:comment\thello big world\thello\treceived",
+			"zroot/a b\tcompression\tlz4\t-\tinherited from zroot",
+			"zroot/c\ttype\tfilesystem\t-\t-",
+		),
+	})
+
+	fs, err := zfs.List("zroot")
+	test.NoError(err)
+	test.Len(fs, 2)
+
+	test.Equal("zroot/a b", fs[0].Name)
+	test.Len(fs[0].Properties, 3)
+	test.Equal("/mnt/my data", fs[0].GetProperty("mountpoint").Value)
+	test.Equal("hello big world", fs[0].GetProperty("x:comment").Value)
+	test.Equal("hello", fs[0].GetProperty("x:comment").Received)
+	test.EqualValues(
+		"inherited from zroot",
+		fs[0].GetProperty("compression").Source.String(),
+	)
+
+	test.Equal("zroot/c", fs[1].Name)
+	test.True(fs[1].IsFileSystem())
+}
+
+func TestZFS_List_ParsesInheritedSource(t *testing.T) {
+	test := assert.New(t)
+
+	zfs, err := NewZFS()
+	test.NoError(err)
+
+	zfs.SetRunner(&runcmd.MockRunner{
+		Stdout: asBytes(
+			"zroot/a/b\tcompression\tlz4\t-\tinherited from zroot/a",
+			"zroot/a/b\tquota\t1024\t-\tlocal",
+			"zroot/a/b\tatime\ton\t-\tdefault",
+		),
+	})
+
+	fs, err := zfs.List("zroot/a/b")
+	test.NoError(err)
+	test.Len(fs, 1)
+
+	compression := fs[0].GetProperty("compression")
+	test.True(compression.IsInherited())
+	test.Equal(SourceInherit, compression.Source.Kind)
+	test.Equal("zroot/a", compression.Source.InheritedFrom)
+
+	test.False(fs[0].GetProperty("quota").IsInherited())
+
+	test.Equal("zroot/a", fs[0].GetPropertyOrigin("compression"))
+	test.Equal("zroot/a/b", fs[0].GetPropertyOrigin("quota"))
+	test.Equal("", fs[0].GetPropertyOrigin("atime"))
+}
+
+func TestZFS_List_ReturnsErrorOnMalformedOutput(t *testing.T) {
+	test := assert.New(t)
+
+	zfs, err := NewZFS()
+	test.NoError(err)
+
+	zfs.SetRunner(&runcmd.MockRunner{
+		Stdout: asBytes(
+			"zroot/a type filesystem - -",
+		),
+	})
+
+	_, err = zfs.List("zroot")
+	test.Error(err)
+	test.Contains(err.Error(), "columns")
+}
+
 func TestZFS_List_ReturnsErrorIfStderrIsNotEmpty(t *testing.T) {
 	test := assert.New(t)
 
@@ -72,115 +362,1496 @@ func TestZFS_List_ReturnsErrorIfStderrIsNotEmpty(t *testing.T) {
 	test.NoError(err)
 
 	zfs.SetRunner(&runcmd.MockRunner{
-		Stderr: asBytes(
-			"no such file or directory: /dev/zfs",
-		),
+		Stderr: asBytes(
+			"no such file or directory: /dev/zfs",
+		),
+	})
+
+	_, err = zfs.List("/")
+	test.Error(err)
+	test.Contains(err.Error(), "no such file or directory")
+}
+
+func TestZFS_GetProperties_ProperlyCallsBinary(t *testing.T) {
+	test := assert.New(t)
+
+	zfs, err := NewZFS()
+	test.NoError(err)
+
+	runner := expectCommands(
+		test, zfs,
+		[]string{
+			"zfs", "get", "-H", "-p",
+			"-o", "name,property,value,received,source",
+			"quota,reservation", "a/b",
+		},
+	)
+
+	runner.Stdout = asBytes(
+		"a/b\tquota\t1024\t-\tlocal",
+		"a/b\treservation\t0\t-\tdefault",
+	)
+
+	fs, err := zfs.GetProperties("a/b", "quota", "reservation")
+	test.NoError(err)
+	test.Equal("a/b", fs.Name)
+	test.Len(fs.Properties, 2)
+
+	size, err := fs.GetProperty("quota").AsSize()
+	test.NoError(err)
+	test.EqualValues(1024, size)
+	test.EqualValues(SourceLocal, fs.GetProperty("quota").Source.Kind)
+}
+
+func TestZFS_SetProperties_ProperlyCallsBinary(t *testing.T) {
+	test := assert.New(t)
+
+	zfs, err := NewZFS()
+	test.NoError(err)
+
+	expectCommand(test, zfs, "zfs", "set", "quota=1G", "x:y=z", "a/b")
+
+	err = zfs.SetProperties("a/b", Properties{
+		{Name: "quota", Value: "1G"},
+		{Name: "x:y", Value: "z"},
+	})
+	test.NoError(err)
+}
+
+func TestZFS_InheritProperty_ProperlyCallsBinary(t *testing.T) {
+	test := assert.New(t)
+
+	zfs, err := NewZFS()
+	test.NoError(err)
+
+	expectCommand(test, zfs, "zfs", "inherit", "quota", "a/b")
+
+	err = zfs.InheritProperty("a/b", "quota", false, false)
+	test.NoError(err)
+
+	expectCommand(test, zfs, "zfs", "inherit", "-r", "-S", "quota", "a/b")
+
+	err = zfs.InheritProperty("a/b", "quota", true, true)
+	test.NoError(err)
+}
+
+func TestZFS_ReturnsTypedErrorsFromStderr(t *testing.T) {
+	test := assert.New(t)
+
+	zfs, err := NewZFS()
+	test.NoError(err)
+
+	testcases := []struct {
+		stderr string
+		kind   error
+	}{
+		{"cannot open 'zroot/x': dataset does not exist", ErrDatasetNotFound},
+		{"cannot create 'zroot/a': dataset already exists", ErrDatasetExists},
+		{"cannot destroy 'zroot/a': dataset is busy", ErrDatasetBusy},
+		{"cannot create 'zroot/a': permission denied", ErrPermissionDenied},
+		{"cannot create 'zroot/a': out of space", ErrOutOfSpace},
+		{
+			"cannot destroy 'zroot/a': filesystem has children\n" +
+				"use '-r' to destroy the following datasets:\nzroot/a/b",
+			ErrHasChildren,
+		},
+		{"cannot open 'tank/a': no such pool 'tank'", ErrNoSuchPool},
+		{
+			"cannot receive new filesystem stream: destination zroot/a " +
+				"contains partially-complete state from \"zfs receive -s\".",
+			ErrReceiveResumeState,
+		},
+	}
+
+	for _, testcase := range testcases {
+		zfs.SetRunner(&runcmd.MockRunner{
+			Stderr: []byte(testcase.stderr),
+		})
+
+		err = zfs.Destroy("zroot/a")
+		test.Error(err)
+		test.True(errors.Is(err, testcase.kind), testcase.stderr)
+
+		var commandErr ErrCommand
+		if test.True(errors.As(err, &commandErr)) {
+			test.Equal(testcase.stderr, string(commandErr.Stderr))
+			test.Contains(commandErr.Command, "zfs destroy zroot/a")
+		}
+	}
+
+	// other entities may exist too, e.g. hold tags
+	zfs.SetRunner(&runcmd.MockRunner{
+		Stderr: []byte(
+			"cannot hold snapshot 'zroot/a@b': " +
+				"tag already exists on this dataset",
+		),
+	})
+
+	err = zfs.Hold("keep", "zroot/a@b")
+	test.Error(err)
+	test.False(errors.Is(err, ErrDatasetExists))
+
+	zfs.SetRunner(&runcmd.MockRunner{
+		Stderr: []byte("something unexpected"),
+	})
+
+	err = zfs.Destroy("zroot/a")
+	test.Error(err)
+	test.False(errors.Is(err, ErrDatasetNotFound))
+}
+
+func TestZFS_Snapshot_ProperlyCallBinary(t *testing.T) {
+	test := assert.New(t)
+
+	zfs, err := NewZFS()
+	test.NoError(err)
+
+	expectCommand(test, zfs, "zfs", "snapshot", "a@b")
+
+	err = zfs.Snapshot("a", "b")
+	test.NoError(err)
+}
+
+func TestZFS_Bookmark_ProperlyCallsBinary(t *testing.T) {
+	test := assert.New(t)
+
+	zfs, err := NewZFS()
+	test.NoError(err)
+
+	expectCommand(test, zfs, "zfs", "bookmark", "zroot/a@1", "zroot/a#1")
+
+	err = zfs.Bookmark("zroot/a@1", "1")
+	test.NoError(err)
+
+	expectCommand(test, zfs, "zfs", "destroy", "zroot/a#1")
+
+	err = zfs.Destroy("zroot/a#1")
+	test.NoError(err)
+}
+
+func TestZFS_ListWithOptions_ListsBookmarks(t *testing.T) {
+	test := assert.New(t)
+
+	zfs, err := NewZFS()
+	test.NoError(err)
+
+	runner := expectCommands(
+		test, zfs,
+		[]string{
+			"zfs", "get", "-H", "-p",
+			"-o", "name,property,value,received,source",
+			"-d", "1", "-t", "bookmark", "all", "zroot/a",
+		},
+	)
+
+	runner.Stdout = asBytes(
+		"zroot/a#1\ttype\tbookmark\t-\t-",
+		"zroot/a#2\ttype\tbookmark\t-\t-",
+	)
+
+	bookmarks, err := zfs.ListWithOptions("zroot/a", ListOptions{
+		Depth: 1,
+		Types: []Type{TypeBookmark},
+	})
+	test.NoError(err)
+	test.Len(bookmarks, 2)
+	test.True(bookmarks[0].IsBookmark())
+	test.False(bookmarks[0].IsSnapshot())
+}
+
+func TestZFS_Send_SendsIncrementalFromBookmark(t *testing.T) {
+	test := assert.New(t)
+
+	zfs, err := NewZFS()
+	test.NoError(err)
+
+	expectCommand(test, zfs, "zfs", "send", "-i", "zroot/a#1", "zroot/a@2")
+
+	err = zfs.Send(
+		"zroot/a@2",
+		ioutil.Discard,
+		SendOptions{Incremental: "zroot/a#1"},
+	)
+	test.NoError(err)
+
+	expectCommand(test, zfs, "zfs", "send", "-i", "#1", "zroot/a@2")
+
+	err = zfs.Send("zroot/a@2", ioutil.Discard, SendOptions{Incremental: "#1"})
+	test.NoError(err)
+}
+
+func TestZFS_Send_ValidatesIncrementalBase(t *testing.T) {
+	test := assert.New(t)
+
+	zfs, err := NewZFS()
+	test.NoError(err)
+
+	executed := false
+	zfs.SetRunner(&runcmd.MockRunner{
+		OnCommand: func(worker *runcmd.MockRunnerWorker) {
+			executed = true
+		},
+	})
+
+	testcases := []SendOptions{
+		{Incremental: "zroot/b#1"},
+		{Incremental: "zroot/b@1"},
+		{Incremental: "zroot/a"},
+		{Incremental: "zroot/a#1", IncludeIntermediary: true},
+	}
+
+	for _, options := range testcases {
+		err = zfs.Send("zroot/a@2", ioutil.Discard, options)
+		test.IsType(ErrInvalidIncrementalBase{}, err, options.Incremental)
+	}
+
+	test.False(executed)
+}
+
+func TestZFS_Destroy_ProperlyCallBinary(t *testing.T) {
+	test := assert.New(t)
+
+	zfs, err := NewZFS()
+	test.NoError(err)
+
+	expectCommand(test, zfs, "zfs", "destroy", "blah")
+
+	err = zfs.Destroy("blah")
+	test.NoError(err)
+}
+
+func TestZFS_Sudo_SetsSudoForNextExecution(t *testing.T) {
+	test := assert.New(t)
+
+	zfs, err := NewZFS()
+	test.NoError(err)
+
+	zfs.Sudo()
+
+	expectCommand(test, zfs, "sudo", "zfs", "destroy", "blah")
+
+	err = zfs.Destroy("blah")
+	test.NoError(err)
+}
+
+func TestZFS_Sudo_IsResetAfterExecution(t *testing.T) {
+	test := assert.New(t)
+
+	zfs, err := NewZFS()
+	test.NoError(err)
+
+	expectCommands(
+		test, zfs,
+		[]string{"sudo", "zfs", "destroy", "a"},
+		[]string{"zfs", "destroy", "b"},
+	)
+
+	test.NoError(zfs.Sudo().Destroy("a"))
+	test.NoError(zfs.Destroy("b"))
+}
+
+func TestZFS_WithEscalator_WrapsEveryExecution(t *testing.T) {
+	test := assert.New(t)
+
+	zfs, err := NewZFS()
+	test.NoError(err)
+
+	privileged := zfs.WithEscalator(EscalatorSudoAs("backup"))
+
+	expectCommand(
+		test, privileged,
+		"sudo", "-n", "-u", "backup", "zfs", "destroy", "blah",
+	)
+
+	err = privileged.Destroy("blah")
+	test.NoError(err)
+
+	err = privileged.Destroy("blah")
+	test.NoError(err)
+
+	expectCommand(test, zfs, "doas", "zfs", "promote", "x")
+
+	err = zfs.WithEscalator(EscalatorDoas).Promote("x")
+	test.NoError(err)
+}
+
+func TestZFS_WithSudo_IsSafeForConcurrentUse(t *testing.T) {
+	test := assert.New(t)
+
+	zfs, err := NewZFS()
+	test.NoError(err)
+
+	var (
+		mutex    sync.Mutex
+		commands = map[string]int{}
+	)
+
+	zfs.SetRunner(&runcmd.MockRunner{
+		OnCommand: func(worker *runcmd.MockRunnerWorker) {
+			mutex.Lock()
+			defer mutex.Unlock()
+
+			commands[strings.Join(worker.GetArgs(), " ")]++
+		},
+	})
+
+	privileged := zfs.WithSudo()
+
+	var group sync.WaitGroup
+	for i := 0; i < 50; i++ {
+		group.Add(2)
+
+		go func() {
+			defer group.Done()
+			test.NoError(privileged.Destroy("a"))
+		}()
+
+		go func() {
+			defer group.Done()
+			test.NoError(zfs.Destroy("b"))
+		}()
+	}
+
+	group.Wait()
+
+	test.Equal(
+		map[string]int{"sudo zfs destroy a": 50, "zfs destroy b": 50},
+		commands,
+	)
+}
+
+func TestZFS_Hold_ProperlyCallsBinary(t *testing.T) {
+	test := assert.New(t)
+
+	zfs, err := NewZFS()
+	test.NoError(err)
+
+	expectCommand(test, zfs, "zfs", "hold", "backup", "a@1", "b@1")
+
+	err = zfs.Hold("backup", "a@1", "b@1")
+	test.NoError(err)
+
+	expectCommand(test, zfs, "zfs", "hold", "-r", "backup", "a@1")
+
+	err = zfs.HoldRecursive("backup", "a@1")
+	test.NoError(err)
+}
+
+func TestZFS_Release_ProperlyCallsBinary(t *testing.T) {
+	test := assert.New(t)
+
+	zfs, err := NewZFS()
+	test.NoError(err)
+
+	expectCommand(test, zfs, "zfs", "release", "backup", "a@1")
+
+	err = zfs.Release("backup", "a@1")
+	test.NoError(err)
+
+	expectCommand(test, zfs, "zfs", "release", "-r", "backup", "a@1")
+
+	err = zfs.ReleaseRecursive("backup", "a@1")
+	test.NoError(err)
+}
+
+func TestZFS_Holds_ReturnsHolds(t *testing.T) {
+	test := assert.New(t)
+
+	zfs, err := NewZFS()
+	test.NoError(err)
+
+	runner := expectCommands(
+		test, zfs,
+		[]string{"zfs", "holds", "-H", "-p", "a@1"},
+	)
+
+	runner.Stdout = asBytes(
+		"a@1\tbackup\t1476700000",
+		"a@1\tkeep me\t1476698700",
+	)
+
+	holds, err := zfs.Holds("a@1")
+	test.NoError(err)
+	test.Equal(
+		[]SnapshotHold{
+			{
+				Snapshot: "a@1",
+				Tag:      "backup",
+				Created:  time.Unix(1476700000, 0),
+			},
+			{
+				Snapshot: "a@1",
+				Tag:      "keep me",
+				Created:  time.Unix(1476698700, 0),
+			},
+		},
+		holds,
+	)
+}
+
+func TestZFS_Destroy_ReturnsErrorIfSnapshotIsHeld(t *testing.T) {
+	test := assert.New(t)
+
+	zfs, err := NewZFS()
+	test.NoError(err)
+
+	zfs.SetRunner(&sequenceRunner{
+		runners: []*runcmd.MockRunner{
+			{
+				Stderr: asBytes(
+					"cannot destroy snapshot a@1: dataset is busy",
+				),
+			},
+			{
+				Stdout: asBytes("a@1\tbackup\t1476700000"),
+			},
+		},
+	})
+
+	err = zfs.Destroy("a@1")
+
+	var held ErrSnapshotHeld
+	if test.True(errors.As(err, &held)) {
+		test.Equal("a@1", held.Snapshot)
+		test.Len(held.Holds, 1)
+		test.Equal("backup", held.Holds[0].Tag)
+	}
+
+	test.True(errors.Is(err, ErrDatasetBusy))
+}
+
+func TestZFS_DestroyRecursive_ProperlyCallBinary(t *testing.T) {
+	test := assert.New(t)
+
+	zfs, err := NewZFS()
+	test.NoError(err)
+
+	expectCommand(test, zfs, "zfs", "destroy", "-r", "blah")
+
+	err = zfs.DestroyRecursive("blah", DestroyScopeLocal)
+	test.NoError(err)
+
+	expectCommand(test, zfs, "zfs", "destroy", "-R", "blah")
+
+	err = zfs.DestroyRecursive("blah", DestroyScopeGlobal)
+	test.NoError(err)
+}
+
+func TestZFS_Rename_ProperlyCallsBinary(t *testing.T) {
+	test := assert.New(t)
+
+	zfs, err := NewZFS()
+	test.NoError(err)
+
+	expectCommand(test, zfs, "zfs", "rename", "a", "b")
+
+	err = zfs.Rename("a", "b", RenameOptions{})
+	test.NoError(err)
+
+	expectCommand(test, zfs, "zfs", "rename", "-p", "-f", "-u", "a", "b/c")
+
+	err = zfs.Rename("a", "b/c", RenameOptions{
+		CreateParents: true,
+		Force:         true,
+		NotMount:      true,
+	})
+	test.NoError(err)
+
+	expectCommand(test, zfs, "zfs", "rename", "-r", "a@x", "a@y")
+
+	err = zfs.Rename("a@x", "a@y", RenameOptions{Recursive: true})
+	test.NoError(err)
+}
+
+func TestZFS_Promote_ProperlyCallsBinary(t *testing.T) {
+	test := assert.New(t)
+
+	zfs, err := NewZFS()
+	test.NoError(err)
+
+	expectCommand(test, zfs, "zfs", "promote", "a/clone")
+
+	err = zfs.Promote("a/clone")
+	test.NoError(err)
+}
+
+func TestZFS_Rollback_ProperlyCallsBinary(t *testing.T) {
+	test := assert.New(t)
+
+	zfs, err := NewZFS()
+	test.NoError(err)
+
+	expectCommand(test, zfs, "zfs", "rollback", "a@b")
+
+	err = zfs.Rollback("a@b")
+	test.NoError(err)
+
+	expectCommand(test, zfs, "zfs", "rollback", "-r", "a@b")
+
+	err = zfs.RollbackRecursive("a@b", DestroyScopeLocal)
+	test.NoError(err)
+
+	expectCommand(test, zfs, "zfs", "rollback", "-R", "a@b")
+
+	err = zfs.RollbackRecursive("a@b", DestroyScopeGlobal)
+	test.NoError(err)
+}
+
+func TestZFS_Clone_ProperlyCallsBinary(t *testing.T) {
+	test := assert.New(t)
+
+	zfs, err := NewZFS()
+	test.NoError(err)
+
+	expectCommand(test, zfs, "zfs", "clone", "a", "b")
+
+	err = zfs.Clone("a", "b")
+	test.NoError(err)
+}
+
+func TestZFS_CloneWithProperties_PassesProperties(t *testing.T) {
+	test := assert.New(t)
+
+	zfs, err := NewZFS()
+	test.NoError(err)
+
+	expectCommand(test, zfs, "zfs", "clone", "-o", "x=y,c=d", "a", "b")
+
+	err = zfs.CloneWithProperties(
+		"a",
+		"b",
+		Properties{
+			{Name: "x", Value: "y"},
+			{Name: "c", Value: "d"},
+		},
+	)
+	test.NoError(err)
+}
+
+func TestZFS_Create_ProperlyCallsBinary(t *testing.T) {
+	test := assert.New(t)
+
+	zfs, err := NewZFS()
+	test.NoError(err)
+
+	runner := expectCommands(
+		test, zfs,
+		[]string{"zfs", "create", "-p", "-o", "x=y", "-o", "c=d", "a/b"},
+		[]string{
+			"zfs", "get", "-H", "-p",
+			"-o", "name,property,value,received,source",
+			"all", "a/b",
+		},
+	)
+
+	runner.Stdout = asBytes("a/b\ttype\tfilesystem\t-\t-")
+
+	_, err = zfs.Create("a/b", CreateOptions{
+		CreateParents: true,
+		Properties: Properties{
+			{Name: "x", Value: "y"},
+			{Name: "c", Value: "d"},
+		},
+	})
+	test.NoError(err)
+}
+
+func TestZFS_Create_CreatesVolume(t *testing.T) {
+	test := assert.New(t)
+
+	zfs, err := NewZFS()
+	test.NoError(err)
+
+	runner := expectCommands(
+		test, zfs,
+		[]string{
+			"zfs", "create", "-s", "-b", "8192", "-V", "1048576", "a/v",
+		},
+		[]string{
+			"zfs", "get", "-H", "-p",
+			"-o", "name,property,value,received,source",
+			"all", "a/v",
+		},
+	)
+
+	runner.Stdout = asBytes("a/v\ttype\tvolume\t-\t-")
+
+	_, err = zfs.Create("a/v", CreateOptions{
+		NotMount:   true,
+		VolumeSize: 1048576,
+		Sparse:     true,
+		BlockSize:  8192,
+	})
+	test.NoError(err)
+}
+
+func TestZFS_Create_RejectsVolumeOptionsForFileSystem(t *testing.T) {
+	test := assert.New(t)
+
+	zfs, err := NewZFS()
+	test.NoError(err)
+
+	zfs.SetRunner(&runcmd.MockRunner{})
+
+	_, err = zfs.Create("a/b", CreateOptions{Sparse: true})
+	test.Equal(ErrVolumeOnlyOption{Option: "-s"}, err)
+
+	_, err = zfs.Create("a/b", CreateOptions{BlockSize: 8192})
+	test.Equal(ErrVolumeOnlyOption{Option: "-b"}, err)
+}
+
+func TestZFS_Create_ReturnsCreatedFS(t *testing.T) {
+	test := assert.New(t)
+
+	zfs, err := NewZFS()
+	test.NoError(err)
+
+	zfs.SetRunner(&runcmd.MockRunner{
+		Stdout: asBytes(
+			"a/b\ttype\tfilesystem\t-\t-",
+			"a/b\tused\t3072\t-\t-",
+		),
+	})
+
+	fs, err := zfs.Create("a/b", CreateOptions{})
+	test.NoError(err)
+	test.Equal("a/b", fs.Name)
+	test.True(fs.IsFileSystem())
+}
+
+func TestZFS_Create_CreatesEncryptedFS(t *testing.T) {
+	test := assert.New(t)
+
+	zfs, err := NewZFS()
+	test.NoError(err)
+
+	runner := expectCommands(
+		test, zfs,
+		[]string{
+			"zfs", "create", "-o", "encryption=on",
+			"-o", "keyformat=passphrase", "-o", "keylocation=prompt", "a/b",
+		},
+		[]string{
+			"zfs", "get", "-H", "-p",
+			"-o", "name,property,value,received,source",
+			"all", "a/b",
+		},
+	)
+
+	runner.Stdout = asBytes(
+		"a/b\tencryption\taes-256-gcm\t-\tlocal",
+		"a/b\tkeystatus\tavailable\t-\t-",
+		"a/b\tencryptionroot\ta/b\t-\t-",
+	)
+
+	fs, err := zfs.Create("a/b", CreateOptions{
+		Encryption:  "on",
+		KeyFormat:   "passphrase",
+		KeyLocation: "prompt",
+		Key:         []byte("secret passphrase"),
+	})
+	test.NoError(err)
+	test.True(fs.IsEncrypted())
+	test.Equal(KeyStatusAvailable, fs.KeyStatus())
+	test.Equal("a/b", fs.EncryptionRoot())
+}
+
+func TestZFS_LoadKey_ProperlyCallsBinary(t *testing.T) {
+	test := assert.New(t)
+
+	zfs, err := NewZFS()
+	test.NoError(err)
+
+	expectCommand(test, zfs, "zfs", "load-key", "-L", "prompt", "a/b")
+
+	err = zfs.LoadKey("a/b", KeySource{Key: []byte("secret")})
+	test.NoError(err)
+
+	expectCommand(
+		test, zfs,
+		"zfs", "load-key", "-r", "-L", "file:///etc/key", "a",
+	)
+
+	err = zfs.LoadKey("a", KeySource{
+		Location:  "file:///etc/key",
+		Recursive: true,
+	})
+	test.NoError(err)
+
+	expectCommand(test, zfs, "zfs", "load-key", "a")
+
+	err = zfs.LoadKey("a", KeySource{})
+	test.NoError(err)
+}
+
+func TestZFS_UnloadKey_ProperlyCallsBinary(t *testing.T) {
+	test := assert.New(t)
+
+	zfs, err := NewZFS()
+	test.NoError(err)
+
+	expectCommand(test, zfs, "zfs", "unload-key", "a/b")
+
+	err = zfs.UnloadKey("a/b")
+	test.NoError(err)
+}
+
+func TestZFS_ChangeKey_ProperlyCallsBinary(t *testing.T) {
+	test := assert.New(t)
+
+	zfs, err := NewZFS()
+	test.NoError(err)
+
+	expectCommand(test, zfs, "zfs", "change-key", "-l", "-i", "a/b")
+
+	err = zfs.ChangeKey("a/b", ChangeKeyOptions{Inherit: true, Load: true})
+	test.NoError(err)
+
+	expectCommand(
+		test, zfs,
+		"zfs", "change-key", "-o", "keyformat=hex",
+		"-o", "keylocation=prompt", "a/b",
+	)
+
+	err = zfs.ChangeKey("a/b", ChangeKeyOptions{
+		KeyFormat:   "hex",
+		KeyLocation: "prompt",
+		Key:         []byte("00ff"),
+	})
+	test.NoError(err)
+}
+
+func TestZFS_KeyMaterial_IsPassedViaStdinAndNeverLogged(t *testing.T) {
+	test := assert.New(t)
+
+	key := []byte("secret key material")
+
+	testcases := []struct {
+		run   func(zfs *ZFS) error
+		stdin []byte
+	}{
+		{
+			func(zfs *ZFS) error {
+				_, err := zfs.Create("a/b", CreateOptions{
+					Encryption:  "on",
+					KeyFormat:   "passphrase",
+					KeyLocation: "prompt",
+					Key:         key,
+				})
+
+				return err
+			},
+			key,
+		},
+		{
+			func(zfs *ZFS) error {
+				_, err := zfs.Create("a/b", CreateOptions{
+					Encryption:  "on",
+					KeyLocation: "file:///etc/key",
+				})
+
+				return err
+			},
+			nil,
+		},
+		{
+			func(zfs *ZFS) error {
+				return zfs.LoadKey("a/b", KeySource{Key: key})
+			},
+			key,
+		},
+		{
+			func(zfs *ZFS) error {
+				return zfs.LoadKey("a/b", KeySource{})
+			},
+			nil,
+		},
+		{
+			func(zfs *ZFS) error {
+				return zfs.ChangeKey("a/b", ChangeKeyOptions{
+					KeyLocation: "prompt",
+					Key:         key,
+				})
+			},
+			key,
+		},
+		{
+			func(zfs *ZFS) error {
+				return zfs.ChangeKey("a/b", ChangeKeyOptions{Inherit: true})
+			},
+			nil,
+		},
+	}
+
+	for _, testcase := range testcases {
+		zfs, err := NewZFS()
+		test.NoError(err)
+
+		logged := &bytes.Buffer{}
+		zfs.SetLogger(
+			func(command []string, stream lexec.Stream, data []byte) {
+				logged.WriteString(strings.Join(command, " "))
+				logged.Write(data)
+			},
+		)
+
+		runner := &keyRunner{}
+		zfs.SetRunner(runner)
+
+		test.NoError(testcase.run(zfs))
+		test.Equal(testcase.stdin, runner.stdin)
+
+		test.Contains(logged.String(), "a/b")
+		test.NotContains(logged.String(), string(key))
+
+		// output is not logged only when key material is passed
+		test.Equal(
+			testcase.stdin == nil,
+			strings.Contains(logged.String(), keyPromptOutput),
+		)
+	}
+}
+
+func TestZFS_Receive_ProperlyCallsBinary(t *testing.T) {
+	test := assert.New(t)
+
+	zfs, err := NewZFS()
+	test.NoError(err)
+
+	expectCommand(test, zfs, "zfs", "receive", "a")
+
+	err = zfs.Receive("a", nopio.NopReader{}, ReceiveOptions{})
+	test.NoError(err)
+}
+
+func TestZFS_Receive_PassesPropertyOverridesAndExcludes(t *testing.T) {
+	test := assert.New(t)
+
+	zfs, err := NewZFS()
+	test.NoError(err)
+
+	expectCommand(
+		test, zfs,
+		"zfs", "receive", "-u", "-o", "origin=a@1",
+		"-o", "canmount=off", "-o", "readonly=on",
+		"-x", "mountpoint", "-x", "sharenfs",
+		"-n", "-h", "-M", "backup/a",
+	)
+
+	err = zfs.Receive("backup/a", nopio.NopReader{}, ReceiveOptions{
+		NotMount: true,
+		Origin:   "a@1",
+		Properties: Properties{
+			{Name: "canmount", Value: "off"},
+			{Name: "readonly", Value: "on"},
+		},
+		ExcludeProperties: []string{"mountpoint", "sharenfs"},
+		DryRun:            true,
+		SkipHolds:         true,
+		ForceUnmount:      true,
+	})
+	test.NoError(err)
+}
+
+func TestZFS_ReceiveVerbose_ParsesReceivedStreams(t *testing.T) {
+	test := assert.New(t)
+
+	zfs, err := NewZFS()
+	test.NoError(err)
+
+	runner := expectCommands(
+		test, zfs,
+		[]string{"zfs", "receive", "-v", "-F", "backup/a"},
+	)
+
+	runner.Stdout = asBytes(
+		"receiving full stream of zroot/a@1 into backup/a@1",
+		"received 1.50M stream in 2 seconds (768K/sec)",
+		"receiving incremental stream of zroot/a@2 into backup/a@2",
+		"received 312B stream in 0.25 seconds (1.22K/sec)",
+	)
+
+	streams, err := zfs.ReceiveVerbose(
+		"backup/a",
+		nopio.NopReader{},
+		ReceiveOptions{ForceRollback: true},
+	)
+	test.NoError(err)
+	test.Equal(
+		[]ReceivedStream{
+			{
+				Type:     "full",
+				Source:   "zroot/a@1",
+				Target:   "backup/a@1",
+				Size:     1572864,
+				Duration: 2 * time.Second,
+			},
+			{
+				Type:     "incremental",
+				Source:   "zroot/a@2",
+				Target:   "backup/a@2",
+				Size:     312,
+				Duration: 250 * time.Millisecond,
+			},
+		},
+		streams,
+	)
+}
+
+func TestZFS_ReceiveVerbose_ParsesDryRun(t *testing.T) {
+	test := assert.New(t)
+
+	zfs, err := NewZFS()
+	test.NoError(err)
+
+	zfs.SetRunner(&runcmd.MockRunner{
+		Stdout: asBytes(
+			"would receive full stream of zroot/a@1 into backup/a@1",
+		),
+	})
+
+	streams, err := zfs.ReceiveVerbose(
+		"backup/a",
+		nopio.NopReader{},
+		ReceiveOptions{DryRun: true},
+	)
+	test.NoError(err)
+	test.Equal(
+		[]ReceivedStream{
+			{
+				Type:   "full",
+				Source: "zroot/a@1",
+				Target: "backup/a@1",
+				DryRun: true,
+			},
+		},
+		streams,
+	)
+}
+
+func TestZFS_ReceiveWithProgress_ReportsReceivedSize(t *testing.T) {
+	test := assert.New(t)
+
+	clock := &manualClock{
+		now:   time.Date(2020, 10, 11, 0, 0, 0, 0, time.Local),
+		ticks: make(chan time.Time),
+	}
+
+	reported := make(chan struct{}, 100)
+
+	zfs, err := NewZFS()
+	test.NoError(err)
+
+	zfs.Runner.clock = clock
+
+	// every chunk is read in one second and reported before next is read
+	zfs.SetRunner(&readingRunner{
+		chunk: 100,
+		onChunk: func() {
+			clock.tick(time.Second)
+			<-reported
+		},
+	})
+
+	reports := []SendProgress{}
+
+	err = zfs.ReceiveWithProgress(
+		"zroot/a",
+		strings.NewReader(strings.Repeat("x", 1000)),
+		ReceiveOptions{},
+		2000,
+		func(progress SendProgress) {
+			reports = append(reports, progress)
+
+			if progress.Reported {
+				reported <- struct{}{}
+			}
+		},
+	)
+	test.NoError(err)
+
+	// initial report, report per every chunk and final report
+	if !test.Len(reports, 12) {
+		return
+	}
+
+	test.False(reports[0].Reported)
+	test.Equal("zroot/a", reports[0].Source)
+	test.EqualValues(2000, reports[0].SendSize)
+
+	for i, progress := range reports[1:11] {
+		test.True(progress.Reported)
+		test.EqualValues(100*(i+1), progress.Report.Size)
+		test.EqualValues(100*(i+1), progress.Report.Sent)
+		test.EqualValues(5*(i+1), progress.Report.Percent)
+		test.EqualValues(100, progress.Report.Rate)
+		test.Equal(
+			time.Duration(20-i-1)*time.Second,
+			progress.Report.Remaining,
+		)
+		test.Equal(-1, progress.Report.SnapshotIndex)
+	}
+
+	last := reports[11]
+	test.True(last.Reported)
+	test.EqualValues(1000, last.Report.Size)
+	test.EqualValues(50, last.Report.Percent)
+	test.Equal(clock.now, last.Report.Timestamp)
+	test.Equal(10*time.Second, last.Report.Remaining)
+}
+
+func TestZFS_ReceiveWithProgress_AcceptsNilCallback(t *testing.T) {
+	test := assert.New(t)
+
+	zfs, err := NewZFS()
+	test.NoError(err)
+
+	expectCommand(test, zfs, "zfs", "receive", "zroot/a")
+
+	err = zfs.ReceiveWithProgress(
+		"zroot/a",
+		strings.NewReader("stream"),
+		ReceiveOptions{},
+		0,
+		nil,
+	)
+	test.NoError(err)
+}
+
+func TestZFS_Send_ReportsProgress(t *testing.T) {
+	test := assert.New(t)
+
+	zfs, err := NewZFS()
+	test.NoError(err)
+
+	zfs.SetRunner(&runcmd.MockRunner{
+		Stderr: asBytes(
+			"full\tzroot/a@c\t1075819232",
+			"size\t1075819233",
+			"15:39:14\t1228816\tzroot/a@c",
+			"15:39:15\t2279888\tzroot/a@c",
+		),
+	})
+
+	sequence := 0
+
+	zfs.SendWithProgress(
+		"blah",
+		ioutil.Discard,
+		SendOptions{},
+		func(progress SendProgress) {
+			switch sequence {
+			case 0:
+				test.EqualValues("full", progress.Type)
+				test.EqualValues("zroot/a@c", progress.Source)
+				test.EqualValues(1075819232, progress.SourceSize)
+				test.EqualValues(1075819233, progress.SendSize)
+				test.EqualValues(false, progress.Reported)
+
+			case 1:
+				test.EqualValues(true, progress.Reported)
+				test.EqualValues("15:39:14", progress.Report.Time)
+				test.EqualValues(1228816, progress.Report.Size)
+				test.EqualValues("zroot/a@c", progress.Report.Snapshot)
+
+			case 2:
+				test.EqualValues(true, progress.Reported)
+				test.EqualValues("15:39:15", progress.Report.Time)
+				test.EqualValues(2279888, progress.Report.Size)
+				test.EqualValues("zroot/a@c", progress.Report.Snapshot)
+			}
+
+			sequence += 1
+		},
+	)
+
+	test.EqualValues(3, sequence)
+}
+
+func TestZFS_WithContext_ReturnsErrorIfContextIsDone(t *testing.T) {
+	test := assert.New(t)
+
+	zfs, err := NewZFS()
+	test.NoError(err)
+
+	executed := false
+	zfs.SetRunner(&runcmd.MockRunner{
+		OnCommand: func(worker *runcmd.MockRunnerWorker) {
+			executed = true
+		},
 	})
 
-	_, err = zfs.List("/")
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	err = zfs.WithContext(ctx).Destroy("blah")
 	test.Error(err)
-	test.Contains(err.Error(), "no such file or directory")
+	test.IsType(ErrCanceled{}, err)
+	test.True(errors.Is(err, context.Canceled))
+	test.False(executed)
+
+	err = zfs.Destroy("blah")
+	test.NoError(err)
+	test.True(executed)
 }
 
-func TestZFS_Snapshot_ProperlyCallBinary(t *testing.T) {
+func TestZFS_WithContext_KillsRunningCommand(t *testing.T) {
 	test := assert.New(t)
 
 	zfs, err := NewZFS()
 	test.NoError(err)
 
-	expectCommand(test, zfs, "zfs", "snapshot", "a@b")
+	runner := &blockingRunner{}
+	zfs.SetRunner(runner)
 
-	err = zfs.Snapshot("a", "b")
-	test.NoError(err)
+	ctx, cancel := context.WithTimeout(
+		context.Background(),
+		10*time.Millisecond,
+	)
+	defer cancel()
+
+	err = zfs.WithContext(ctx).Send("a@b", ioutil.Discard, SendOptions{})
+	test.Error(err)
+	test.True(errors.Is(err, context.DeadlineExceeded))
+	test.True(runner.worker.isKilled())
 }
 
-func TestZFS_Destroy_ProperlyCallBinary(t *testing.T) {
+// blockingRunner returns commands which are running until they are killed.
+type blockingRunner struct {
+	runcmd.MockRunner
+
+	worker *blockingWorker
+}
+
+func (runner *blockingRunner) Command(
+	name string,
+	args ...string,
+) runcmd.CmdWorker {
+	runner.worker = &blockingWorker{
+		CmdWorker: runner.MockRunner.Command(name, args...),
+		killed:    make(chan struct{}),
+	}
+
+	return runner.worker
+}
+
+type blockingWorker struct {
+	runcmd.CmdWorker
+
+	killed chan struct{}
+}
+
+func (worker *blockingWorker) Start() error {
+	return nil
+}
+
+func (worker *blockingWorker) Wait() error {
+	<-worker.killed
+
+	return errors.New("killed")
+}
+
+func (worker *blockingWorker) Output() ([]byte, []byte, error) {
+	return nil, nil, worker.Wait()
+}
+
+func (worker *blockingWorker) Kill() error {
+	close(worker.killed)
+
+	return nil
+}
+
+func (worker *blockingWorker) isKilled() bool {
+	select {
+	case <-worker.killed:
+		return true
+	default:
+		return false
+	}
+}
+
+func TestZFS_SendWithProgress_ReportsRateAndSnapshotBoundaries(t *testing.T) {
 	test := assert.New(t)
 
 	zfs, err := NewZFS()
 	test.NoError(err)
 
-	expectCommand(test, zfs, "zfs", "destroy", "blah")
+	zfs.SetRunner(&runcmd.MockRunner{
+		Stderr: asBytes(
+			"incremental\ta@1\ta@2\t1000",
+			"incremental\ta@2\ta@3\t3000",
+			"size\t4000",
+			"23:59:58\t500\ta@2",
+			"23:59:59\t1000\ta@2",
+			"00:00:00\t1000\ta@3",
+			"00:00:01\t2000\ta@3",
+		),
+	})
 
-	err = zfs.Destroy("blah")
+	reports := []SendProgress{}
+
+	err = zfs.SendWithProgress(
+		"a@3",
+		ioutil.Discard,
+		SendOptions{Incremental: "a@1", IncludeIntermediary: true},
+		func(progress SendProgress) {
+			reports = append(reports, progress)
+		},
+	)
 	test.NoError(err)
+
+	if !test.Len(reports, 5) {
+		return
+	}
+
+	test.Len(reports[0].Estimate.Snapshots, 2)
+	test.EqualValues(4000, reports[0].SendSize)
+
+	first := reports[1].Report
+	test.Equal(0, first.SnapshotIndex)
+	test.False(first.SnapshotChanged)
+	test.EqualValues(500, first.Sent)
+	test.EqualValues(0, first.Rate)
+	test.EqualValues(12.5, first.Percent)
+	test.EqualValues(0, first.Remaining)
+	test.Equal(58, first.Timestamp.Second())
+
+	test.EqualValues(500, reports[2].Report.Rate)
+
+	changed := reports[3].Report
+	test.True(changed.SnapshotChanged)
+	test.Equal(1, changed.SnapshotIndex)
+	test.EqualValues(2000, changed.Sent)
+	test.EqualValues(650, changed.Rate)
+	test.Equal(
+		time.Second,
+		changed.Timestamp.Sub(reports[2].Report.Timestamp),
+	)
+
+	last := reports[4].Report
+	test.False(last.SnapshotChanged)
+	test.EqualValues(3000, last.Sent)
+	test.InDelta(755, last.Rate, 0.001)
+	test.EqualValues(75, last.Percent)
+	test.InDelta(
+		float64(time.Second)*1000/755,
+		float64(last.Remaining),
+		float64(time.Millisecond),
+	)
 }
 
-func TestZFS_Sudo_SetsSudoForNextExecution(t *testing.T) {
+func TestZFS_SendWithProgress_CountsSnapshotsSentBetweenReports(
+	t *testing.T,
+) {
 	test := assert.New(t)
 
 	zfs, err := NewZFS()
 	test.NoError(err)
 
-	zfs.Sudo()
+	zfs.SetRunner(&runcmd.MockRunner{
+		Stderr: asBytes(
+			"incremental\ta@1\ta@2\t1000",
+			"incremental\ta@2\ta@3\t1000",
+			"incremental\ta@3\ta@4\t1000",
+			"size\t3000",
+			"00:00:00\t600\ta@2",
+			"00:00:01\t100\ta@4",
+		),
+	})
 
-	expectCommand(test, zfs, "sudo", "zfs", "destroy", "blah")
+	reports := []SendProgress{}
 
-	err = zfs.Destroy("blah")
+	err = zfs.SendWithProgress(
+		"a@4",
+		ioutil.Discard,
+		SendOptions{Incremental: "a@1", IncludeIntermediary: true},
+		func(progress SendProgress) {
+			reports = append(reports, progress)
+		},
+	)
 	test.NoError(err)
+
+	if !test.Len(reports, 3) {
+		return
+	}
+
+	last := reports[2].Report
+	test.True(last.SnapshotChanged)
+	test.Equal(2, last.SnapshotIndex)
+	test.EqualValues(2100, last.Sent)
+	test.InDelta(70, last.Percent, 0.001)
+	test.EqualValues(1500, last.Rate)
 }
 
-func TestZFS_DestroyRecursive_ProperlyCallBinary(t *testing.T) {
+func TestZFS_Send_PassesRawAndExtendedOptions(t *testing.T) {
 	test := assert.New(t)
 
 	zfs, err := NewZFS()
 	test.NoError(err)
 
-	expectCommand(test, zfs, "zfs", "destroy", "-r", "blah")
+	expectCommand(
+		test, zfs,
+		"zfs", "send", "-w", "-h", "-b", "-D", "-V",
+		"--redact", "a#book", "a@b",
+	)
 
-	err = zfs.DestroyRecursive("blah", DestroyScopeLocal)
+	err = zfs.Send("a@b", ioutil.Discard, SendOptions{
+		Raw:              true,
+		Holds:            true,
+		BackupProperties: true,
+		Deduplicated:     true,
+		ProcessTitle:     true,
+		Redact:           "a#book",
+	})
 	test.NoError(err)
 
-	expectCommand(test, zfs, "zfs", "destroy", "-R", "blah")
+	expectCommand(test, zfs, "zfs", "send", "--saved", "a")
 
-	err = zfs.DestroyRecursive("blah", DestroyScopeGlobal)
+	err = zfs.Send("a", ioutil.Discard, SendOptions{Saved: true})
 	test.NoError(err)
 }
 
-func TestZFS_Clone_ProperlyCallsBinary(t *testing.T) {
+func TestZFS_Send_ValidatesOptionsBeforeExecution(t *testing.T) {
 	test := assert.New(t)
 
 	zfs, err := NewZFS()
 	test.NoError(err)
 
-	expectCommand(test, zfs, "zfs", "clone", "a", "b")
+	executed := false
+	zfs.SetRunner(&runcmd.MockRunner{
+		OnCommand: func(worker *runcmd.MockRunnerWorker) {
+			executed = true
+		},
+	})
 
-	err = zfs.Clone("a", "b")
+	err = zfs.Send(
+		"a@b",
+		ioutil.Discard,
+		SendOptions{Raw: true, Compressed: true},
+	)
+	test.Equal(ErrIncompatibleSendOptions{First: "-w", Second: "-c"}, err)
+
+	_, err = zfs.EstimateSend(
+		"a@b",
+		SendOptions{Saved: true, ReplicationStream: true},
+	)
+	test.Equal(ErrIncompatibleSendOptions{First: "--saved", Second: "-R"}, err)
+
+	test.False(executed)
+}
+
+func TestZFS_EstimateSend_ProperlyCallsBinary(t *testing.T) {
+	test := assert.New(t)
+
+	zfs, err := NewZFS()
+	test.NoError(err)
+
+	runner := expectCommands(
+		test, zfs,
+		[]string{"zfs", "send", "-R", "-n", "-v", "-P", "zroot/a@c"},
+	)
+
+	runner.Stdout = asBytes(
+		"full\tzroot/a@c\t1024",
+		"size\t1024",
+	)
+
+	estimate, err := zfs.EstimateSend(
+		"zroot/a@c",
+		SendOptions{ReplicationStream: true},
+	)
 	test.NoError(err)
+	test.Equal("full", estimate.Type)
+	test.EqualValues(1024, estimate.Size)
 }
 
-func TestZFS_CloneWithProperties_PassesProperties(t *testing.T) {
+func TestZFS_EstimateSend_ReturnsPerSnapshotSizes(t *testing.T) {
 	test := assert.New(t)
 
 	zfs, err := NewZFS()
 	test.NoError(err)
 
-	expectCommand(test, zfs, "zfs", "clone", "-o", "x=y,c=d", "a", "b")
+	zfs.SetRunner(&runcmd.MockRunner{
+		Stderr: asBytes(
+			"incremental\tzroot/a@1\tzroot/a@2\t100",
+			"incremental\tzroot/a@2\tzroot/a@3\t200",
+			"size\t300",
+		),
+	})
 
-	err = zfs.CloneWithProperties(
-		"a",
-		"b",
-		Properties{
-			{Name: "x", Value: "y"},
-			{Name: "c", Value: "d"},
+	estimate, err := zfs.EstimateSend(
+		"zroot/a@3",
+		SendOptions{Incremental: "zroot/a@1", IncludeIntermediary: true},
+	)
+	test.NoError(err)
+	test.Equal(
+		SendEstimate{
+			Type: "incremental",
+			Snapshots: []SendEstimateSnapshot{
+				{
+					Type:     "incremental",
+					From:     "zroot/a@1",
+					Snapshot: "zroot/a@2",
+					Size:     100,
+				},
+				{
+					Type:     "incremental",
+					From:     "zroot/a@2",
+					Snapshot: "zroot/a@3",
+					Size:     200,
+				},
+			},
+			Size: 300,
+		},
+		estimate,
+	)
+}
+
+func TestZFS_GetResumeToken_ReturnsToken(t *testing.T) {
+	test := assert.New(t)
+
+	zfs, err := NewZFS()
+	test.NoError(err)
+
+	runner := expectCommands(
+		test, zfs,
+		[]string{
+			"zfs", "get", "-H", "-p",
+			"-o", "name,property,value,received,source",
+			"receive_resume_token", "zroot/a",
 		},
 	)
+
+	runner.Stdout = asBytes(
+		"zroot/a\treceive_resume_token\t1-abc-def\t-\t-",
+	)
+
+	token, err := zfs.GetResumeToken("zroot/a")
+	test.NoError(err)
+	test.Equal("1-abc-def", token)
+
+	zfs.SetRunner(&runcmd.MockRunner{
+		Stdout: asBytes("zroot/a\treceive_resume_token\t-\t-\t-"),
+	})
+
+	token, err = zfs.GetResumeToken("zroot/a")
 	test.NoError(err)
+	test.Equal("", token)
 }
 
-func TestZFS_Receive_ProperlyCallsBinary(t *testing.T) {
+func TestZFS_SendResume_ProperlyCallsBinary(t *testing.T) {
 	test := assert.New(t)
 
 	zfs, err := NewZFS()
 	test.NoError(err)
 
-	expectCommand(test, zfs, "zfs", "receive", "a")
+	expectCommand(test, zfs, "zfs", "send", "-t", "1-abc-def")
 
-	err = zfs.Receive("a", nopio.NopReader{}, ReceiveOptions{})
+	err = zfs.SendResume("1-abc-def", ioutil.Discard, nil)
 	test.NoError(err)
 }
 
-func TestZFS_Send_ReportsProgress(t *testing.T) {
+func TestZFS_SendResume_ReportsProgress(t *testing.T) {
 	test := assert.New(t)
 
 	zfs, err := NewZFS()
@@ -188,46 +1859,179 @@ func TestZFS_Send_ReportsProgress(t *testing.T) {
 
 	zfs.SetRunner(&runcmd.MockRunner{
 		Stderr: asBytes(
+			"resume token contents:",
+			"nvlist version: 0",
+			"\tobject = 0x6",
+			"\ttoname = zroot/a@c",
 			"full\tzroot/a@c\t1075819232",
 			"size\t1075819233",
 			"15:39:14\t1228816\tzroot/a@c",
-			"15:39:15\t2279888\tzroot/a@c",
 		),
 	})
 
-	sequence := 0
+	reports := []SendProgress{}
 
-	zfs.SendWithProgress(
-		"blah",
+	err = zfs.SendResume(
+		"1-abc-def",
 		ioutil.Discard,
-		SendOptions{},
 		func(progress SendProgress) {
-			switch sequence {
-			case 0:
-				test.EqualValues("full", progress.Type)
-				test.EqualValues("zroot/a@c", progress.Source)
-				test.EqualValues(1075819232, progress.SourceSize)
-				test.EqualValues(1075819233, progress.SendSize)
-				test.EqualValues(false, progress.Reported)
+			reports = append(reports, progress)
+		},
+	)
+	test.NoError(err)
 
-			case 1:
-				test.EqualValues(true, progress.Reported)
-				test.EqualValues("15:39:14", progress.Report.Time)
-				test.EqualValues(1228816, progress.Report.Size)
-				test.EqualValues("zroot/a@c", progress.Report.Snapshot)
+	if test.Len(reports, 2) {
+		test.NoError(reports[0].Error)
+		test.EqualValues("zroot/a@c", reports[0].Source)
+		test.EqualValues(1075819233, reports[0].SendSize)
+		test.EqualValues(1228816, reports[1].Report.Size)
+	}
+}
 
-			case 2:
-				test.EqualValues(true, progress.Reported)
-				test.EqualValues("15:39:15", progress.Report.Time)
-				test.EqualValues(2279888, progress.Report.Size)
-				test.EqualValues("zroot/a@c", progress.Report.Snapshot)
-			}
+func TestZFS_AbortPartialReceive_ProperlyCallsBinary(t *testing.T) {
+	test := assert.New(t)
 
-			sequence += 1
-		},
-	)
+	zfs, err := NewZFS()
+	test.NoError(err)
 
-	test.EqualValues(3, sequence)
+	expectCommand(test, zfs, "zfs", "receive", "-A", "zroot/a")
+
+	err = zfs.AbortPartialReceive("zroot/a")
+	test.NoError(err)
+}
+
+// sequenceRunner executes every next command using next mock runner.
+type sequenceRunner struct {
+	runners []*runcmd.MockRunner
+	index   int
+}
+
+func (runner *sequenceRunner) Command(
+	name string,
+	args ...string,
+) runcmd.CmdWorker {
+	mock := runner.runners[runner.index]
+	runner.index++
+
+	return mock.Command(name, args...)
+}
+
+// keyPromptOutput is printed by commands of keyRunner, like zfs prints key
+// prompt.
+const keyPromptOutput = "Enter passphrase: "
+
+// keyRunner returns commands which print key prompt followed by stdin, and
+// remembers stdin of key management command. `zfs get` commands print
+// properties of encrypted FS.
+type keyRunner struct {
+	runcmd.MockRunner
+
+	stdin []byte
+}
+
+func (runner *keyRunner) Command(
+	name string,
+	args ...string,
+) runcmd.CmdWorker {
+	return &keyWorker{
+		CmdWorker: runner.MockRunner.Command(name, args...),
+		runner:    runner,
+	}
+}
+
+type keyWorker struct {
+	runcmd.CmdWorker
+
+	runner *keyRunner
+	stdin  io.Reader
+}
+
+func (worker *keyWorker) SetStdin(stdin io.Reader) {
+	worker.stdin = stdin
+}
+
+func (worker *keyWorker) Output() ([]byte, []byte, error) {
+	if worker.GetArgs()[1] == "get" {
+		return asBytes("a/b\tencryption\taes-256-gcm\t-\tlocal"), nil, nil
+	}
+
+	if worker.stdin != nil {
+		stdin, err := ioutil.ReadAll(worker.stdin)
+		if err != nil {
+			return nil, nil, err
+		}
+
+		worker.runner.stdin = stdin
+	}
+
+	return append([]byte(keyPromptOutput), worker.runner.stdin...), nil, nil
+}
+
+// manualClock is a clock which ticks only when tick() is called.
+type manualClock struct {
+	now   time.Time
+	ticks chan time.Time
+}
+
+func (clock *manualClock) Now() time.Time {
+	return clock.now
+}
+
+func (clock *manualClock) Ticker(time.Duration) (<-chan time.Time, func()) {
+	return clock.ticks, func() {}
+}
+
+// tick advances clock by given duration and delivers tick.
+func (clock *manualClock) tick(elapsed time.Duration) {
+	clock.now = clock.now.Add(elapsed)
+	clock.ticks <- clock.now
+}
+
+// readingRunner returns commands which are reading stdin in chunks and call
+// onChunk after every chunk.
+type readingRunner struct {
+	runcmd.MockRunner
+
+	chunk   int
+	onChunk func()
+}
+
+func (runner *readingRunner) Command(
+	name string,
+	args ...string,
+) runcmd.CmdWorker {
+	return &readingWorker{
+		CmdWorker: runner.MockRunner.Command(name, args...),
+		runner:    runner,
+	}
+}
+
+type readingWorker struct {
+	runcmd.CmdWorker
+
+	runner *readingRunner
+	stdin  io.Reader
+}
+
+func (worker *readingWorker) SetStdin(stdin io.Reader) {
+	worker.stdin = stdin
+}
+
+func (worker *readingWorker) Output() ([]byte, []byte, error) {
+	buffer := make([]byte, worker.runner.chunk)
+
+	for {
+		_, err := worker.stdin.Read(buffer)
+		if err == io.EOF {
+			return nil, nil, nil
+		}
+
+		if err != nil {
+			return nil, nil, err
+		}
+
+		worker.runner.onChunk()
+	}
 }
 
 func expectCommand(test *assert.Assertions, zfs *ZFS, args ...string) {
@@ -238,6 +2042,35 @@ func expectCommand(test *assert.Assertions, zfs *ZFS, args ...string) {
 	})
 }
 
+func expectCommands(
+	test *assert.Assertions,
+	zfs *ZFS,
+	commands ...[]string,
+) *runcmd.MockRunner {
+	runner := newCommandsMock(test, commands...)
+
+	zfs.SetRunner(runner)
+
+	return runner
+}
+
+func newCommandsMock(
+	test *assert.Assertions,
+	commands ...[]string,
+) *runcmd.MockRunner {
+	sequence := 0
+
+	return &runcmd.MockRunner{
+		OnCommand: func(worker *runcmd.MockRunnerWorker) {
+			if test.True(sequence < len(commands), "unexpected command") {
+				test.EqualValues(commands[sequence], worker.GetArgs())
+			}
+
+			sequence++
+		},
+	}
+}
+
 func asBytes(lines ...string) []byte {
 	return []byte(strings.Join(lines, "\n"))
 }
@@ -0,0 +1,358 @@
+package zfs
+
+import (
+	"bytes"
+	"context"
+	"strconv"
+	"strings"
+
+	"github.com/kovetskiy/runcmd"
+	"github.com/reconquest/lexec-go"
+	"github.com/reconquest/ser-go"
+)
+
+// Zpool is a handle to access various zpool operations. Like ZFS, it can work
+// with local or remote pools, depending on runner, and shares same
+// concurrency guarantees.
+type Zpool struct {
+	*Runner
+}
+
+// NewZpool returns new zpool handle linked to local pools.
+func NewZpool() (*Zpool, error) {
+	return &Zpool{&Runner{Binary: "zpool", Runner: NewLocalRunner()}}, nil
+}
+
+// WithSudo is a same as Runner.WithSudo(), but returns zpool handle.
+func (zpool *Zpool) WithSudo() *Zpool {
+	return &Zpool{zpool.Runner.WithSudo()}
+}
+
+// WithEscalator is a same as Runner.WithEscalator(), but returns zpool
+// handle.
+func (zpool *Zpool) WithEscalator(escalator Escalator) *Zpool {
+	return &Zpool{zpool.Runner.WithEscalator(escalator)}
+}
+
+// WithContext is a same as Runner.WithContext(), but returns zpool handle.
+func (zpool *Zpool) WithContext(ctx context.Context) *Zpool {
+	return &Zpool{zpool.Runner.WithContext(ctx)}
+}
+
+// SetRunner is a same as Runner.SetRunner(), but returns zpool handle.
+func (zpool *Zpool) SetRunner(runner runcmd.Runner) *Zpool {
+	zpool.Runner.SetRunner(runner)
+
+	return zpool
+}
+
+// SetLogger is a same as Runner.SetLogger(), but returns zpool handle.
+func (zpool *Zpool) SetLogger(logger lexec.Logger) *Zpool {
+	zpool.Runner.SetLogger(logger)
+
+	return zpool
+}
+
+// List lists all imported pools.
+func (zpool *Zpool) List() ([]Pool, error) {
+	command := zpool.Command(
+		"list", "-H", "-p", "-o", strings.Join(poolColumns, ","),
+	)
+
+	stdout, _, err := command.Output()
+	if err != nil {
+		return nil, err
+	}
+
+	pools, err := readPools(bytes.NewReader(stdout))
+	if err != nil {
+		return nil, ser.Errorf(
+			err,
+			"error while reading command output: '%s'",
+			command.String(),
+		)
+	}
+
+	return pools, nil
+}
+
+// Status returns status of specified pool, including vdev tree, scan state
+// and errors.
+func (zpool *Zpool) Status(pool string) (PoolStatus, error) {
+	return zpool.status("-p", pool)
+}
+
+func (zpool *Zpool) status(args ...string) (PoolStatus, error) {
+	command := zpool.Command(append([]string{"status"}, args...)...)
+
+	stdout, _, err := command.Output()
+	if err != nil {
+		return PoolStatus{}, err
+	}
+
+	status, err := parsePoolStatus(bytes.NewReader(stdout))
+	if err != nil {
+		return PoolStatus{}, ser.Errorf(
+			err,
+			"error while reading command output: '%s'",
+			command.String(),
+		)
+	}
+
+	return status, nil
+}
+
+// ScanProgress returns progress of current or last scrub or resilver of
+// specified pool.
+func (zpool *Zpool) ScanProgress(pool string) (ScanProgress, error) {
+	status, err := zpool.Status(pool)
+	if err != nil {
+		return ScanProgress{}, err
+	}
+
+	progress, err := status.ScanProgress()
+	if err != nil {
+		return ScanProgress{}, ser.Errorf(
+			err,
+			"can't parse scan status of pool %s",
+			pool,
+		)
+	}
+
+	return progress, nil
+}
+
+// TrimProgress returns TRIM progress of every leaf device of specified pool.
+func (zpool *Zpool) TrimProgress(pool string) ([]TrimProgress, error) {
+	status, err := zpool.status("-p", "-t", pool)
+	if err != nil {
+		return nil, err
+	}
+
+	progress, err := status.TrimProgress()
+	if err != nil {
+		return nil, ser.Errorf(
+			err,
+			"can't parse trim status of pool %s",
+			pool,
+		)
+	}
+
+	return progress, nil
+}
+
+// Scrub starts scrub of specified pool or resumes paused one.
+func (zpool *Zpool) Scrub(pool string) error {
+	return zpool.Command("scrub", pool).Execute()
+}
+
+// PauseScrub pauses scrub of specified pool, it can be resumed later by
+// Scrub().
+func (zpool *Zpool) PauseScrub(pool string) error {
+	return zpool.Command("scrub", "-p", pool).Execute()
+}
+
+// StopScrub stops scrub of specified pool.
+func (zpool *Zpool) StopScrub(pool string) error {
+	return zpool.Command("scrub", "-s", pool).Execute()
+}
+
+// Trim starts TRIM of specified devices of pool or all devices if none is
+// specified.
+func (zpool *Zpool) Trim(
+	pool string,
+	options TrimOptions,
+	devices ...string,
+) error {
+	args := []string{"trim"}
+
+	if options.Secure {
+		args = append(args, "-d")
+	}
+
+	if options.Rate > 0 {
+		args = append(args, "-r", strconv.FormatInt(options.Rate.AsInt64(), 10))
+	}
+
+	if options.Wait {
+		args = append(args, "-w")
+	}
+
+	args = append(args, pool)
+
+	return zpool.Command(append(args, devices...)...).Execute()
+}
+
+// SuspendTrim suspends TRIM of specified devices of pool or all devices if
+// none is specified. It can be resumed later by Trim().
+func (zpool *Zpool) SuspendTrim(pool string, devices ...string) error {
+	args := append([]string{"trim", "-s", pool}, devices...)
+
+	return zpool.Command(args...).Execute()
+}
+
+// CancelTrim cancels TRIM of specified devices of pool or all devices if
+// none is specified.
+func (zpool *Zpool) CancelTrim(pool string, devices ...string) error {
+	args := append([]string{"trim", "-c", pool}, devices...)
+
+	return zpool.Command(args...).Execute()
+}
+
+// Initialize starts writing initialization pattern to unallocated space of
+// specified devices of pool or all devices if none is specified.
+func (zpool *Zpool) Initialize(pool string, devices ...string) error {
+	args := append([]string{"initialize", pool}, devices...)
+
+	return zpool.Command(args...).Execute()
+}
+
+// Clear clears device errors of pool. If device is empty, errors of all pool
+// devices are cleared.
+func (zpool *Zpool) Clear(pool string, device string) error {
+	args := []string{"clear", pool}
+
+	if device != "" {
+		args = append(args, device)
+	}
+
+	return zpool.Command(args...).Execute()
+}
+
+// Create creates new pool with specified layout.
+func (zpool *Zpool) Create(
+	pool string,
+	spec VdevSpec,
+	options CreatePoolOptions,
+) error {
+	vdevs, err := spec.args()
+	if err != nil {
+		return err
+	}
+
+	args := []string{"create"}
+
+	if options.Force {
+		args = append(args, "-f")
+	}
+
+	if options.Mountpoint != "" {
+		args = append(args, "-m", options.Mountpoint)
+	}
+
+	if options.AltRoot != "" {
+		args = append(args, "-R", options.AltRoot)
+	}
+
+	for _, pair := range options.Properties.Pairs() {
+		args = append(args, "-o", pair)
+	}
+
+	for _, pair := range options.FSProperties.Pairs() {
+		args = append(args, "-O", pair)
+	}
+
+	args = append(append(args, pool), vdevs...)
+
+	return zpool.Command(args...).Execute()
+}
+
+// Destroy destroys specified pool and frees it's devices.
+func (zpool *Zpool) Destroy(pool string) error {
+	return zpool.Command("destroy", pool).Execute()
+}
+
+// Import imports specified pool. If pool is empty, all pools which are found
+// are imported.
+func (zpool *Zpool) Import(pool string, options ImportOptions) error {
+	args := []string{"import"}
+
+	for _, dir := range options.SearchDirs {
+		args = append(args, "-d", dir)
+	}
+
+	if options.NotMount {
+		args = append(args, "-N")
+	}
+
+	if options.AltRoot != "" {
+		args = append(args, "-R", options.AltRoot)
+	}
+
+	if options.Force {
+		args = append(args, "-f")
+	}
+
+	for _, pair := range options.Properties.Pairs() {
+		args = append(args, "-o", pair)
+	}
+
+	if pool == "" {
+		args = append(args, "-a")
+	} else {
+		args = append(args, pool)
+	}
+
+	return zpool.Command(args...).Execute()
+}
+
+// Export exports specified pool, so it can be imported on another system.
+func (zpool *Zpool) Export(pool string, options ExportOptions) error {
+	args := []string{"export"}
+
+	if options.Force {
+		args = append(args, "-f")
+	}
+
+	return zpool.Command(append(args, pool)...).Execute()
+}
+
+// Attach attaches new device to existing device of pool, so they become
+// mirror (or existing mirror becomes wider).
+func (zpool *Zpool) Attach(pool string, device string, newDevice string) error {
+	return zpool.Command("attach", pool, device, newDevice).Execute()
+}
+
+// Detach detaches device from mirror.
+func (zpool *Zpool) Detach(pool string, device string) error {
+	return zpool.Command("detach", pool, device).Execute()
+}
+
+// Replace replaces device of pool with new device. If new device is empty,
+// device is replaced with itself, e.g. after physical disk replacement.
+func (zpool *Zpool) Replace(
+	pool string,
+	device string,
+	newDevice string,
+) error {
+	args := []string{"replace", pool, device}
+
+	if newDevice != "" {
+		args = append(args, newDevice)
+	}
+
+	return zpool.Command(args...).Execute()
+}
+
+// Online brings specified devices of pool online.
+func (zpool *Zpool) Online(pool string, devices ...string) error {
+	args := append([]string{"online", pool}, devices...)
+
+	return zpool.Command(args...).Execute()
+}
+
+// Offline takes specified devices of pool offline. Devices remain offline
+// after reboot.
+func (zpool *Zpool) Offline(pool string, devices ...string) error {
+	args := append([]string{"offline", pool}, devices...)
+
+	return zpool.Command(args...).Execute()
+}
+
+// OfflineTemporary is a same as Offline(), but devices will be back online
+// after reboot.
+func (zpool *Zpool) OfflineTemporary(pool string, devices ...string) error {
+	args := append([]string{"offline", "-t", pool}, devices...)
+
+	return zpool.Command(args...).Execute()
+}
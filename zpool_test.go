@@ -0,0 +1,618 @@
+package zfs
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/kovetskiy/runcmd"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestZpool_List_ParsesPools(t *testing.T) {
+	test := assert.New(t)
+
+	zpool, err := NewZpool()
+	test.NoError(err)
+
+	runner := expectZpoolCommands(
+		test, zpool,
+		[]string{
+			"zpool", "list", "-H", "-p", "-o",
+			"name,size,alloc,free,frag,cap,dedup,health,altroot",
+		},
+	)
+	runner.Stdout = asBytes(
+		"tank\t1000\t250\t750\t12\t25\t1.50\tONLINE\t-",
+		"backup\t2000\t0\t2000\t-\t0\t1.00x\tDEGRADED\t/mnt",
+	)
+
+	pools, err := zpool.List()
+	test.NoError(err)
+	test.Equal(
+		[]Pool{
+			{
+				Name:          "tank",
+				Size:          1000,
+				Allocated:     250,
+				Free:          750,
+				Fragmentation: 12,
+				Capacity:      25,
+				Dedup:         1.5,
+				Health:        HealthOnline,
+			},
+			{
+				Name:    "backup",
+				Size:    2000,
+				Free:    2000,
+				Dedup:   1,
+				Health:  HealthDegraded,
+				AltRoot: "/mnt",
+			},
+		},
+		pools,
+	)
+}
+
+func TestZpool_Status_ParsesVdevTree(t *testing.T) {
+	test := assert.New(t)
+
+	zpool, err := NewZpool()
+	test.NoError(err)
+
+	runner := expectZpoolCommands(
+		test, zpool,
+		[]string{"zpool", "status", "-p", "tank"},
+	)
+	runner.Stdout = asBytes(
+		"  pool: tank",
+		" state: DEGRADED",
+		"status: One or more devices could not be used because the label is",
+		"\tmissing or invalid.",
+		"action: Replace the device using 'zpool replace'.",
+		"   see: https://openzfs.github.io/openzfs-docs/msg/ZFS-8000-4J",
+		"  scan: scrub in progress since Sun Oct 11 00:24:01 2020",
+		"\t1.50G scanned at 100M/s, 1.00G issued at 50M/s, 10.0G total",
+		"\t0B repaired, 10.00% done, 00:03:04 to go",
+		"config:",
+		"",
+		"\tNAME        STATE     READ WRITE CKSUM",
+		"\ttank        DEGRADED     0     0     0",
+		"\t  mirror-0  DEGRADED     0     0     0",
+		"\t    sda     ONLINE       0     0     3",
+		"\t    sdb     UNAVAIL      0     0     0  was /dev/sdb1",
+		"\t  sdc       ONLINE       1     2     0",
+		"\tlogs",
+		"\t  sdd       ONLINE       0     0     0",
+		"\tcache",
+		"\t  sde       ONLINE       0     0     0",
+		"\tspares",
+		"\t  sdf       AVAIL",
+		"\t  sdg       INUSE     currently in use",
+		"",
+		"errors: No known data errors",
+	)
+
+	status, err := zpool.Status("tank")
+	test.NoError(err)
+	test.Equal(
+		PoolStatus{
+			Name:  "tank",
+			State: HealthDegraded,
+			Status: "One or more devices could not be used because " +
+				"the label is\nmissing or invalid.",
+			Action: "Replace the device using 'zpool replace'.",
+			See:    "https://openzfs.github.io/openzfs-docs/msg/ZFS-8000-4J",
+			Scan: "scrub in progress since Sun Oct 11 00:24:01 2020\n" +
+				"1.50G scanned at 100M/s, 1.00G issued at 50M/s, " +
+				"10.0G total\n" +
+				"0B repaired, 10.00% done, 00:03:04 to go",
+			Vdevs: Vdev{
+				Name:  "tank",
+				State: HealthDegraded,
+				Children: []Vdev{
+					{
+						Name:  "mirror-0",
+						State: HealthDegraded,
+						Children: []Vdev{
+							{Name: "sda", State: HealthOnline, Checksum: 3},
+							{
+								Name:    "sdb",
+								State:   HealthUnavailable,
+								Message: "was /dev/sdb1",
+							},
+						},
+					},
+					{Name: "sdc", State: HealthOnline, Read: 1, Write: 2},
+				},
+			},
+			Logs:  []Vdev{{Name: "sdd", State: HealthOnline}},
+			Cache: []Vdev{{Name: "sde", State: HealthOnline}},
+			Spares: []Vdev{
+				{Name: "sdf", State: HealthAvailable},
+				{
+					Name:    "sdg",
+					State:   HealthInUse,
+					Message: "currently in use",
+				},
+			},
+			Errors: "No known data errors",
+		},
+		status,
+	)
+}
+
+func TestZpool_Status_ParsesPermanentErrors(t *testing.T) {
+	test := assert.New(t)
+
+	zpool, err := NewZpool()
+	test.NoError(err)
+
+	zpool.SetRunner(&runcmd.MockRunner{
+		Stdout: asBytes(
+			"  pool: tank",
+			" state: ONLINE",
+			"config:",
+			"",
+			"\tNAME  STATE     READ WRITE CKSUM",
+			"\ttank  ONLINE       0     0     2",
+			"\t  sda ONLINE       0     0     2",
+			"",
+			"errors: Permanent errors have been detected in the "+
+				"following files:",
+			"",
+			"        tank:<0x1>",
+			"        /tank/file with spaces",
+		),
+	})
+
+	status, err := zpool.Status("tank")
+	test.NoError(err)
+	test.Equal(
+		"Permanent errors have been detected in the following files:\n"+
+			"tank:<0x1>\n"+
+			"/tank/file with spaces",
+		status.Errors,
+	)
+	test.EqualValues(2, status.Vdevs.Children[0].Checksum)
+}
+
+func TestZpool_Status_ReturnsNoSuchPoolError(t *testing.T) {
+	test := assert.New(t)
+
+	zpool, err := NewZpool()
+	test.NoError(err)
+
+	zpool.SetRunner(&runcmd.MockRunner{
+		Stderr: []byte("cannot open 'tank': no such pool"),
+	})
+
+	_, err = zpool.Status("tank")
+	test.True(errors.Is(err, ErrNoSuchPool))
+}
+
+func TestZpool_WithSudo_RunsZpoolUnderSudo(t *testing.T) {
+	test := assert.New(t)
+
+	zpool, err := NewZpool()
+	test.NoError(err)
+
+	zpool = zpool.WithSudo()
+
+	expectZpoolCommands(
+		test, zpool,
+		[]string{"sudo", "zpool", "status", "-p", "tank"},
+	).Stdout = asBytes("  pool: tank", " state: ONLINE")
+
+	_, err = zpool.Status("tank")
+	test.NoError(err)
+}
+
+func TestZpool_MaintenanceCommands(t *testing.T) {
+	test := assert.New(t)
+
+	testcases := []struct {
+		run  func(zpool *Zpool) error
+		args []string
+	}{
+		{
+			func(zpool *Zpool) error { return zpool.Scrub("tank") },
+			[]string{"zpool", "scrub", "tank"},
+		},
+		{
+			func(zpool *Zpool) error { return zpool.PauseScrub("tank") },
+			[]string{"zpool", "scrub", "-p", "tank"},
+		},
+		{
+			func(zpool *Zpool) error { return zpool.StopScrub("tank") },
+			[]string{"zpool", "scrub", "-s", "tank"},
+		},
+		{
+			func(zpool *Zpool) error {
+				return zpool.Trim("tank", TrimOptions{})
+			},
+			[]string{"zpool", "trim", "tank"},
+		},
+		{
+			func(zpool *Zpool) error {
+				return zpool.Trim(
+					"tank",
+					TrimOptions{Rate: 1048576, Secure: true, Wait: true},
+					"sda", "sdb",
+				)
+			},
+			[]string{
+				"zpool", "trim", "-d", "-r", "1048576", "-w",
+				"tank", "sda", "sdb",
+			},
+		},
+		{
+			func(zpool *Zpool) error { return zpool.SuspendTrim("tank") },
+			[]string{"zpool", "trim", "-s", "tank"},
+		},
+		{
+			func(zpool *Zpool) error {
+				return zpool.CancelTrim("tank", "sda")
+			},
+			[]string{"zpool", "trim", "-c", "tank", "sda"},
+		},
+		{
+			func(zpool *Zpool) error {
+				return zpool.Initialize("tank", "sda")
+			},
+			[]string{"zpool", "initialize", "tank", "sda"},
+		},
+		{
+			func(zpool *Zpool) error { return zpool.Clear("tank", "") },
+			[]string{"zpool", "clear", "tank"},
+		},
+		{
+			func(zpool *Zpool) error { return zpool.Clear("tank", "sda") },
+			[]string{"zpool", "clear", "tank", "sda"},
+		},
+	}
+
+	for _, testcase := range testcases {
+		zpool, err := NewZpool()
+		test.NoError(err)
+
+		expectZpoolCommands(test, zpool, testcase.args)
+
+		test.NoError(testcase.run(zpool))
+	}
+}
+
+func TestZpool_ScanProgress_ParsesScrubInProgress(t *testing.T) {
+	test := assert.New(t)
+
+	zpool, err := NewZpool()
+	test.NoError(err)
+
+	expectZpoolCommands(
+		test, zpool,
+		[]string{"zpool", "status", "-p", "tank"},
+	).Stdout = asBytes(
+		"  pool: tank",
+		" state: ONLINE",
+		"  scan: scrub in progress since Sun Oct 11 00:24:01 2020",
+		"	1.50G scanned at 100M/s, 1.00G issued at 50M/s, 10.0G total",
+		"	0B repaired, 10.00% done, 1 days 00:03:04 to go",
+	)
+
+	progress, err := zpool.ScanProgress("tank")
+	test.NoError(err)
+	test.Equal(
+		ScanProgress{
+			Function:  ScanScrub,
+			State:     ScanScanning,
+			Time:      time.Date(2020, 10, 11, 0, 24, 1, 0, time.Local),
+			Scanned:   Size(1.5 * 1024 * 1024 * 1024),
+			Issued:    1024 * 1024 * 1024,
+			Total:     10 * 1024 * 1024 * 1024,
+			ScanRate:  100 * 1024 * 1024,
+			IssueRate: 50 * 1024 * 1024,
+			Percent:   10,
+			Remaining: 24*time.Hour + 3*time.Minute + 4*time.Second,
+		},
+		progress,
+	)
+}
+
+func TestPoolStatus_ScanProgress(t *testing.T) {
+	test := assert.New(t)
+
+	testcases := []struct {
+		scan     string
+		progress ScanProgress
+	}{
+		{
+			"none requested",
+			ScanProgress{State: ScanNone},
+		},
+		{
+			"scrub repaired 1M in 0 days 00:00:05 with 2 errors on " +
+				"Sun Oct  4 00:24:01 2020",
+			ScanProgress{
+				Function: ScanScrub,
+				State:    ScanFinished,
+				Time:     time.Date(2020, 10, 4, 0, 24, 1, 0, time.Local),
+				Repaired: 1024 * 1024,
+				Percent:  100,
+				Duration: 5 * time.Second,
+				Errors:   2,
+			},
+		},
+		{
+			"resilvered 2G in 01:00:00 with 0 errors on " +
+				"Sun Oct 11 00:24:01 2020",
+			ScanProgress{
+				Function: ScanResilver,
+				State:    ScanFinished,
+				Time:     time.Date(2020, 10, 11, 0, 24, 1, 0, time.Local),
+				Repaired: 2 * 1024 * 1024 * 1024,
+				Percent:  100,
+				Duration: time.Hour,
+			},
+		},
+		{
+			"scrub canceled on Sun Oct 11 00:24:01 2020",
+			ScanProgress{
+				Function: ScanScrub,
+				State:    ScanCanceled,
+				Time:     time.Date(2020, 10, 11, 0, 24, 1, 0, time.Local),
+			},
+		},
+		{
+			"scrub paused since Sun Oct 11 00:24:01 2020\n" +
+				"scrub started on Sun Oct 11 00:00:00 2020\n" +
+				"4G / 8G scanned, 2G / 8G issued\n" +
+				"0B repaired, 25.00% done",
+			ScanProgress{
+				Function: ScanScrub,
+				State:    ScanPaused,
+				Time:     time.Date(2020, 10, 11, 0, 24, 1, 0, time.Local),
+				Scanned:  4 * 1024 * 1024 * 1024,
+				Issued:   2 * 1024 * 1024 * 1024,
+				Total:    8 * 1024 * 1024 * 1024,
+				Percent:  25,
+			},
+		},
+		{
+			"resilver in progress since Sun Oct 11 00:24:01 2020\n" +
+				"1G scanned out of 4G at 1M/s, 0h51m to go\n" +
+				"512M resilvered, 25.00% done",
+			ScanProgress{
+				Function:  ScanResilver,
+				State:     ScanScanning,
+				Time:      time.Date(2020, 10, 11, 0, 24, 1, 0, time.Local),
+				Scanned:   1024 * 1024 * 1024,
+				Total:     4 * 1024 * 1024 * 1024,
+				ScanRate:  1024 * 1024,
+				Repaired:  512 * 1024 * 1024,
+				Percent:   25,
+				Remaining: 51 * time.Minute,
+			},
+		},
+	}
+
+	for _, testcase := range testcases {
+		progress, err := PoolStatus{Scan: testcase.scan}.ScanProgress()
+		test.NoError(err, testcase.scan)
+		test.Equal(testcase.progress, progress, testcase.scan)
+	}
+
+	_, err := PoolStatus{Scan: "something new"}.ScanProgress()
+	test.Error(err)
+}
+
+func TestZpool_TrimProgress_ParsesLeafDevices(t *testing.T) {
+	test := assert.New(t)
+
+	zpool, err := NewZpool()
+	test.NoError(err)
+
+	expectZpoolCommands(
+		test, zpool,
+		[]string{"zpool", "status", "-p", "-t", "tank"},
+	).Stdout = asBytes(
+		"  pool: tank",
+		" state: ONLINE",
+		"config:",
+		"",
+		"\tNAME        STATE     READ WRITE CKSUM",
+		"\ttank        ONLINE       0     0     0",
+		"\t  mirror-0  ONLINE       0     0     0",
+		"\t    sda     ONLINE       0     0     0  "+
+			"(12% trimmed, started at Sun Oct 11 00:24:01 2020)",
+		"\t    sdb     ONLINE       0     0     0  "+
+			"(40% trimmed, suspended, started at Sun Oct 11 00:24:01 2020)",
+		"\t  sdc       ONLINE       0     0     0  (untrimmed)",
+		"\tlogs",
+		"\t  sdd       ONLINE       0     0     0  "+
+			"(100% trimmed, completed at Sun Oct 11 01:00:00 2020)",
+		"\tcache",
+		"\t  sde       ONLINE       0     0     0  (trim unsupported)",
+	)
+
+	progress, err := zpool.TrimProgress("tank")
+	test.NoError(err)
+
+	started := time.Date(2020, 10, 11, 0, 24, 1, 0, time.Local)
+
+	test.Equal(
+		[]TrimProgress{
+			{Device: "sda", State: TrimActive, Percent: 12, Time: started},
+			{
+				Device:  "sdb",
+				State:   TrimSuspended,
+				Percent: 40,
+				Time:    started,
+			},
+			{Device: "sdc", State: TrimUntrimmed},
+			{
+				Device:  "sdd",
+				State:   TrimComplete,
+				Percent: 100,
+				Time:    time.Date(2020, 10, 11, 1, 0, 0, 0, time.Local),
+			},
+		},
+		progress,
+	)
+}
+
+func TestZpool_Create_BuildsVdevLayout(t *testing.T) {
+	test := assert.New(t)
+
+	zpool, err := NewZpool()
+	test.NoError(err)
+
+	expectZpoolCommands(
+		test, zpool,
+		[]string{
+			"zpool", "create", "-f", "-m", "/data", "-R", "/mnt",
+			"-o", "ashift=12", "-O", "compression=lz4",
+			"tank",
+			"mirror", "sda", "sdb",
+			"raidz2", "sdc", "sdd", "sde", "sdf",
+			"draid1:4d:1s", "sdg", "sdh", "sdi", "sdj", "sdk", "sdl",
+			"log", "mirror", "nvme0", "nvme1",
+			"special", "mirror", "ssd0", "ssd1",
+			"cache", "nvme2",
+			"spare", "sdm",
+		},
+	)
+
+	err = zpool.Create(
+		"tank",
+		VdevSpec{
+			Data: []VdevGroup{
+				{Type: VdevMirror, Devices: []string{"sda", "sdb"}},
+				{
+					Type:    VdevRaidz2,
+					Devices: []string{"sdc", "sdd", "sde", "sdf"},
+				},
+				{
+					Type: VdevDraid1,
+					Devices: []string{
+						"sdg", "sdh", "sdi", "sdj", "sdk", "sdl",
+					},
+					DraidData:   4,
+					DraidSpares: 1,
+				},
+			},
+			Log: []VdevGroup{
+				{Type: VdevMirror, Devices: []string{"nvme0", "nvme1"}},
+			},
+			Special: []VdevGroup{
+				{Type: VdevMirror, Devices: []string{"ssd0", "ssd1"}},
+			},
+			Cache:  []string{"nvme2"},
+			Spares: []string{"sdm"},
+		},
+		CreatePoolOptions{
+			Properties:   Properties{{Name: "ashift", Value: "12"}},
+			FSProperties: Properties{{Name: "compression", Value: "lz4"}},
+			Mountpoint:   "/data",
+			AltRoot:      "/mnt",
+			Force:        true,
+		},
+	)
+	test.NoError(err)
+}
+
+func TestZpool_Create_RejectsInvalidLayout(t *testing.T) {
+	test := assert.New(t)
+
+	zpool, err := NewZpool()
+	test.NoError(err)
+
+	expectZpoolCommands(test, zpool)
+
+	specs := []VdevSpec{
+		{},
+		{Data: []VdevGroup{{Type: VdevMirror}}},
+		{
+			Data: []VdevGroup{
+				{
+					Type:      VdevRaidz1,
+					Devices:   []string{"sda", "sdb", "sdc"},
+					DraidData: 2,
+				},
+			},
+		},
+	}
+
+	for _, spec := range specs {
+		err := zpool.Create("tank", spec, CreatePoolOptions{})
+		test.IsType(ErrInvalidVdevSpec{}, err)
+	}
+}
+
+func TestZpool_ImportExport(t *testing.T) {
+	test := assert.New(t)
+
+	zpool, err := NewZpool()
+	test.NoError(err)
+
+	expectZpoolCommands(
+		test, zpool,
+		[]string{
+			"zpool", "import", "-d", "/dev/disk/by-id", "-d", "/images",
+			"-N", "-R", "/mnt", "-f", "-o", "readonly=on", "tank",
+		},
+		[]string{"zpool", "import", "-a"},
+		[]string{"zpool", "export", "tank"},
+		[]string{"zpool", "export", "-f", "tank"},
+	)
+
+	test.NoError(zpool.Import("tank", ImportOptions{
+		SearchDirs: []string{"/dev/disk/by-id", "/images"},
+		NotMount:   true,
+		AltRoot:    "/mnt",
+		Force:      true,
+		Properties: Properties{{Name: "readonly", Value: "on"}},
+	}))
+	test.NoError(zpool.Import("", ImportOptions{}))
+	test.NoError(zpool.Export("tank", ExportOptions{}))
+	test.NoError(zpool.Export("tank", ExportOptions{Force: true}))
+}
+
+func TestZpool_DeviceCommands(t *testing.T) {
+	test := assert.New(t)
+
+	zpool, err := NewZpool()
+	test.NoError(err)
+
+	expectZpoolCommands(
+		test, zpool,
+		[]string{"zpool", "attach", "tank", "sda", "sdb"},
+		[]string{"zpool", "detach", "tank", "sdb"},
+		[]string{"zpool", "replace", "tank", "sda", "sdc"},
+		[]string{"zpool", "replace", "tank", "sda"},
+		[]string{"zpool", "online", "tank", "sda", "sdb"},
+		[]string{"zpool", "offline", "tank", "sda"},
+		[]string{"zpool", "offline", "-t", "tank", "sdb"},
+		[]string{"zpool", "destroy", "tank"},
+	)
+
+	test.NoError(zpool.Attach("tank", "sda", "sdb"))
+	test.NoError(zpool.Detach("tank", "sdb"))
+	test.NoError(zpool.Replace("tank", "sda", "sdc"))
+	test.NoError(zpool.Replace("tank", "sda", ""))
+	test.NoError(zpool.Online("tank", "sda", "sdb"))
+	test.NoError(zpool.Offline("tank", "sda"))
+	test.NoError(zpool.OfflineTemporary("tank", "sdb"))
+	test.NoError(zpool.Destroy("tank"))
+}
+
+func expectZpoolCommands(
+	test *assert.Assertions,
+	zpool *Zpool,
+	commands ...[]string,
+) *runcmd.MockRunner {
+	runner := newCommandsMock(test, commands...)
+
+	zpool.SetRunner(runner)
+
+	return runner
+}